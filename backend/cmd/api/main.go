@@ -2,17 +2,23 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"embed"
+	"time"
 
 	"github.com/joho/godotenv"
 
+	"price-analyzer-backend/internal/analysis"
 	"price-analyzer-backend/internal/auth"
 	"price-analyzer-backend/internal/data"
+	"price-analyzer-backend/internal/fx"
+	"price-analyzer-backend/internal/money"
 	"price-analyzer-backend/internal/server"
+	"price-analyzer-backend/internal/snapshot"
 	"price-analyzer-backend/internal/web"
 	"price-analyzer-backend/internal/worker"
 )
@@ -22,8 +28,29 @@ type AddRequest struct {
 }
 
 type AlertRequest struct {
-	ID          int     `json:"id"`
-	TargetPrice float64 `json:"target_price"`
+	ID          int          `json:"id"`
+	TargetPrice money.Amount `json:"target_price"`
+}
+
+// PreviewResponse mostra o que o scraper leu de uma URL, sem salvar nada.
+type PreviewResponse struct {
+	URL          string       `json:"url"`
+	CanonicalURL string       `json:"canonical_url"`
+	Title        string       `json:"title"`
+	ImageURL     string       `json:"image_url"`
+	Price        money.Amount `json:"price"`
+	CashPrice    money.Amount `json:"cash_price,omitempty"`
+	CardPrice    money.Amount `json:"card_price,omitempty"`
+	ListPrice    money.Amount `json:"list_price,omitempty"`
+	Discount     float64      `json:"discount_percent,omitempty"`
+	Currency     string       `json:"currency"`
+	Availability string       `json:"availability"`
+	Extractor    string       `json:"extractor"`
+	Strategy     string       `json:"strategy"`
+	Confidence   float64      `json:"confidence"`
+	NeedsConfirm bool         `json:"needs_confirmation"`
+	Warning      string       `json:"warning,omitempty"`
+	ExistingID   int          `json:"existing_id,omitempty"`
 }
 
 var migrationFiles embed.FS
@@ -47,6 +74,27 @@ func main() {
 	log.Println("Verificando migrações...")
     data.RunMigrations(dbURL)
 
+	if path := os.Getenv("FX_RATES_FILE"); path != "" {
+		rates, err := fx.LoadStaticFile(path)
+		if err != nil {
+			log.Fatal("Erro ao carregar tabela de câmbio:", err)
+		}
+		fx.SetProvider(rates)
+	}
+
+	web.ConfigureClient(web.ClientConfigFromEnv())
+	web.ConfigurePoliteness(web.PolitenessConfigFromEnv())
+	web.ConfigureRetries(web.RetryConfigFromEnv())
+	if err := web.ConfigureStores(web.StoresConfigFromEnv()); err != nil {
+		log.Fatal("Erro ao carregar definições de lojas:", err)
+	}
+
+	if store := snapshot.FromEnv(); store != nil {
+		snapshot.Configure(store)
+		snapshot.StartJanitor(time.Hour)
+		log.Println("📦 Guardando snapshots de HTML em", store.Dir)
+	}
+
 	worker.StartPriceMonitor()
 	worker.StartTelegramListener()
 
@@ -56,9 +104,15 @@ func main() {
 	http.HandleFunc("/user/settings", server.AuthenticateMiddleware(handleUserSettings))
 
 	http.HandleFunc("/products", server.AuthenticateMiddleware(handleProducts))
+	http.HandleFunc("/products/preview", server.AuthenticateMiddleware(handlePreviewProduct))
 	http.HandleFunc("/product/info", server.AuthenticateMiddleware(handleProductInfo))
 	http.HandleFunc("/product/alert", server.AuthenticateMiddleware(handleAlertSetup))
 	http.HandleFunc("/product/delete", server.AuthenticateMiddleware(handleDeleteProduct))
+	http.HandleFunc("/product/analysis", server.AuthenticateMiddleware(handleProductAnalysis))
+	http.HandleFunc("/product/attempts", server.AuthenticateMiddleware(handleScrapeAttempts))
+	http.HandleFunc("/product/suspicious", server.AuthenticateMiddleware(handleSuspiciousSamples))
+
+	http.HandleFunc("/scraper/status", server.AuthenticateMiddleware(handleScraperStatus))
 	
 	http.HandleFunc("/product", server.AuthenticateMiddleware(handleProductDetails)) 
 
@@ -98,6 +152,12 @@ func handleProducts(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Erro ao buscar produtos", http.StatusInternalServerError)
 			return
 		}
+
+		if user, err := data.GetUserByID(userID); err == nil {
+			for i := range products {
+				applyDisplayCurrency(&products[i], user.DisplayCurrency)
+			}
+		}
 		json.NewEncoder(w).Encode(products)
 		return
 	}
@@ -109,33 +169,114 @@ func handleProducts(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		title, image, price, err := web.ScrapeProduct(req.URL)
+		canonical, err := web.CanonicalURL(r.Context(), req.URL)
+		if err != nil {
+			http.Error(w, "URL inválida: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if existing, err := data.GetProductByCanonicalURL(userID, canonical); err == nil {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(existing)
+			return
+		}
+
+		started := time.Now()
+		scraped, err := web.ScrapeProduct(req.URL)
 		if err != nil {
 			http.Error(w, "Erro no scraper: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 
 		newProduct := data.Product{
-			Name:         title,
+			Name:         scraped.Title,
 			URL:          req.URL,
-			ImageURL:     image,
-			CurrentPrice: price,
+			CanonicalURL: canonical,
+			ImageURL:     scraped.ImageURL,
+			CurrentPrice: scraped.Price,
+			Currency:     scraped.Currency,
+			Availability: string(scraped.Availability),
             UserID:       userID,
 		}
 
 		id, err := data.CreateProduct(newProduct)
+		if errors.Is(err, data.ErrDuplicateProduct) {
+			http.Error(w, "Produto já cadastrado", http.StatusConflict)
+			return
+		}
 		if err != nil {
 			http.Error(w, "Erro ao salvar no banco: "+err.Error(), 500)
 			return
 		}
 
-		data.UpdatePrice(id, price)
+		data.UpdatePrice(id, scraped.Sample())
+		worker.RecordAttempt(id, started, scraped.Problem())
 
 		newProduct.ID = id
 		json.NewEncoder(w).Encode(newProduct)
 	}
 }
 
+func handlePreviewProduct(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" { return }
+
+	if r.Method != "POST" {
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := server.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "ID de usuário ausente.", http.StatusUnauthorized)
+		return
+	}
+
+	var req AddRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	canonical, err := web.CanonicalURL(r.Context(), req.URL)
+	if err != nil {
+		http.Error(w, "URL inválida: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	scraped, err := web.PreviewProduct(req.URL)
+	if err != nil {
+		http.Error(w, "Erro no scraper: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	resp := PreviewResponse{
+		URL:          req.URL,
+		CanonicalURL: canonical,
+		Title:        scraped.Title,
+		ImageURL:     scraped.ImageURL,
+		Price:        scraped.Price,
+		CashPrice:    scraped.CashPrice,
+		CardPrice:    scraped.CardPrice,
+		ListPrice:    scraped.ListPrice,
+		Discount:     scraped.DiscountPercent(),
+		Currency:     scraped.Currency,
+		Availability: string(scraped.Availability),
+		Extractor:    scraped.Extractor,
+		Strategy:     scraped.Strategy,
+		Confidence:   scraped.Confidence,
+		NeedsConfirm: scraped.NeedsConfirmation(),
+	}
+	if problem := scraped.Problem(); problem != nil {
+		resp.Warning = problem.Error()
+	}
+	if existing, err := data.GetProductByCanonicalURL(userID, canonical); err == nil {
+		resp.ExistingID = existing.ID
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
 func handleProductDetails(w http.ResponseWriter, r *http.Request) {
     enableCors(&w)
     if r.Method == "OPTIONS" { return }
@@ -164,6 +305,95 @@ func handleProductDetails(w http.ResponseWriter, r *http.Request) {
     json.NewEncoder(w).Encode(history)
 }
 
+func handleProductAnalysis(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" { return }
+
+	userID, ok := server.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "ID de usuário ausente.", http.StatusUnauthorized)
+		return
+	}
+
+	idStr := r.URL.Query().Get("id")
+	if idStr == "" {
+		http.Error(w, "ID é obrigatório", 400)
+		return
+	}
+
+	var id int
+	fmt.Sscanf(idStr, "%d", &id)
+
+	if _, err := data.GetProductByID(id, userID); err != nil {
+		http.Error(w, "Produto não encontrado", 404)
+		return
+	}
+
+	result, err := analysis.AnalyzeProduct(id, userID)
+	if err != nil {
+		http.Error(w, "Erro ao analisar histórico: "+err.Error(), 500)
+		return
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
+
+func handleScrapeAttempts(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" { return }
+
+	userID, ok := server.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "ID de usuário ausente.", http.StatusUnauthorized)
+		return
+	}
+
+	idStr := r.URL.Query().Get("id")
+	if idStr == "" {
+		http.Error(w, "ID é obrigatório", 400)
+		return
+	}
+
+	var id int
+	fmt.Sscanf(idStr, "%d", &id)
+
+	attempts, err := data.GetScrapeAttempts(id, userID, 50)
+	if err != nil {
+		http.Error(w, "Erro ao buscar tentativas: "+err.Error(), 500)
+		return
+	}
+
+	json.NewEncoder(w).Encode(attempts)
+}
+
+func handleSuspiciousSamples(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" { return }
+
+	userID, ok := server.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "ID de usuário ausente.", http.StatusUnauthorized)
+		return
+	}
+
+	idStr := r.URL.Query().Get("id")
+	if idStr == "" {
+		http.Error(w, "ID é obrigatório", 400)
+		return
+	}
+
+	var id int
+	fmt.Sscanf(idStr, "%d", &id)
+
+	samples, err := data.GetSuspiciousSamples(id, userID)
+	if err != nil {
+		http.Error(w, "Erro ao buscar leituras suspeitas: "+err.Error(), 500)
+		return
+	}
+
+	json.NewEncoder(w).Encode(samples)
+}
+
 func handleProductInfo(w http.ResponseWriter, r *http.Request) {
 	enableCors(&w)
 	if r.Method == "OPTIONS" { return }
@@ -189,6 +419,10 @@ func handleProductInfo(w http.ResponseWriter, r *http.Request) {
         return
     }
 
+	if user, err := data.GetUserByID(userID); err == nil {
+		applyDisplayCurrency(&product, user.DisplayCurrency)
+	}
+
 	json.NewEncoder(w).Encode(product)
 }
 
@@ -211,9 +445,13 @@ func handleAlertSetup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Campos ausentes ficam como estão: dá para ligar só o aviso de estoque
+	// sem mexer no preço-alvo, ou trocar o preço-alvo sem perder o tipo.
 	type AlertRequest struct {
-		ID          int     `json:"id"`
-		TargetPrice float64 `json:"target_price"`
+		ID          int           `json:"id"`
+		TargetPrice *money.Amount `json:"target_price"`
+		PriceType   *string       `json:"price_type"`
+		StockAlert  *bool         `json:"stock_alert"`
 	}
 
 	var req AlertRequest
@@ -222,12 +460,40 @@ func handleAlertSetup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := data.UpdateTargetPrice(req.ID, userID, req.TargetPrice)
-	if err != nil {
-		http.Error(w, "Erro ao atualizar alerta: "+err.Error(), 500)
+	if req.TargetPrice == nil && req.PriceType == nil && req.StockAlert == nil {
+		http.Error(w, "Informe target_price, price_type ou stock_alert", 400)
 		return
 	}
 
+	var priceType *string
+	if req.PriceType != nil {
+		kind := web.PriceKind(*req.PriceType)
+		if kind == "" {
+			kind = web.PriceDefault
+		}
+		if !kind.Valid() {
+			http.Error(w, "Tipo de preço inválido", 400)
+			return
+		}
+		t := string(kind)
+		priceType = &t
+	}
+
+	if req.TargetPrice != nil || priceType != nil {
+		err := data.UpdateTargetPrice(req.ID, userID, req.TargetPrice, priceType)
+		if err != nil {
+			http.Error(w, "Erro ao atualizar alerta: "+err.Error(), 500)
+			return
+		}
+	}
+
+	if req.StockAlert != nil {
+		if err := data.UpdateStockAlert(req.ID, userID, *req.StockAlert); err != nil {
+			http.Error(w, "Erro ao atualizar alerta: "+err.Error(), 500)
+			return
+		}
+	}
+
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"status":"updated"}`))
 }
@@ -264,6 +530,13 @@ func handleDeleteProduct(w http.ResponseWriter, r *http.Request) {
     w.WriteHeader(http.StatusNoContent)
 }
 
+func handleScraperStatus(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" { return }
+
+	json.NewEncoder(w).Encode(web.BreakerStatuses())
+}
+
 func enableCors(w *http.ResponseWriter) {
 	(*w).Header().Set("Access-Control-Allow-Origin", "*")
 	(*w).Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, DELETE")
@@ -301,7 +574,8 @@ func handleUserSettings(w http.ResponseWriter, r *http.Request) {
 
     if r.Method == "POST" {
         type SettingsReq struct {
-            TelegramChatID string `json:"telegram_chat_id"`
+            TelegramChatID  string `json:"telegram_chat_id"`
+            DisplayCurrency string `json:"display_currency"`
         }
         var req SettingsReq
         if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -314,6 +588,30 @@ func handleUserSettings(w http.ResponseWriter, r *http.Request) {
             http.Error(w, "Erro ao salvar: "+err.Error(), 500)
             return
         }
+
+        if req.DisplayCurrency != "" {
+            currency := fx.Normalize(req.DisplayCurrency)
+            if _, err := fx.Convert(1, "BRL", currency); err != nil {
+                http.Error(w, "Moeda não suportada: "+currency, 400)
+                return
+            }
+            if err := data.UpdateUserCurrency(userID, currency); err != nil {
+                http.Error(w, "Erro ao salvar: "+err.Error(), 500)
+                return
+            }
+        }
         w.WriteHeader(http.StatusOK)
     }
-}
\ No newline at end of file
+}
+
+// applyDisplayCurrency preenche o preço convertido para a moeda preferida do
+// usuário. Sem cotação disponível, o produto segue só com o preço original.
+func applyDisplayCurrency(p *data.Product, currency string) {
+	currency = fx.Normalize(currency)
+	converted, err := fx.Convert(p.CurrentPrice, p.Currency, currency)
+	if err != nil {
+		return
+	}
+	p.DisplayPrice = converted
+	p.DisplayCurrency = currency
+}
@@ -0,0 +1,76 @@
+// Comando canonicalize recalcula a URL canônica dos produtos cadastrados
+// antes da coluna canonical_url existir. A migração 000008 copiou a URL
+// original, com parâmetros de rastreamento e links curtos, e esses produtos
+// não batiam com a URL canônica de um novo cadastro do mesmo item.
+//
+//	go run ./cmd/canonicalize -dry-run
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+
+	"github.com/joho/godotenv"
+
+	"price-analyzer-backend/internal/data"
+	"price-analyzer-backend/internal/web"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "só mostra o que mudaria, sem gravar")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Aviso: Arquivo .env não encontrado, usando variáveis de ambiente do OS.")
+	}
+
+	storesCfg := web.StoresConfigFromEnv()
+	storesCfg.ReloadInterval = 0
+	if err := web.ConfigureStores(storesCfg); err != nil {
+		log.Fatal("Erro ao carregar definições de lojas:", err)
+	}
+	web.ConfigureClient(web.ClientConfigFromEnv())
+	web.ConfigurePoliteness(web.PolitenessConfigFromEnv())
+
+	data.ConnectDB()
+
+	products, err := data.GetAllProductURLs()
+	if err != nil {
+		log.Fatal("Erro ao buscar produtos:", err)
+	}
+
+	var updated, duplicates, failed int
+	for _, p := range products {
+		canonical, err := web.CanonicalURL(context.Background(), p.URL)
+		if err != nil {
+			log.Printf("❌ Produto %d: %v", p.ID, err)
+			failed++
+			continue
+		}
+		if canonical == p.CanonicalURL {
+			continue
+		}
+
+		log.Printf("✏️ Produto %d: %s -> %s", p.ID, p.CanonicalURL, canonical)
+		if *dryRun {
+			updated++
+			continue
+		}
+		err = data.UpdateCanonicalURL(p.ID, canonical)
+		switch {
+		case errors.Is(err, data.ErrDuplicateProduct):
+			// Inclui as duplicatas que a migração marcou com #dup-<id>.
+			log.Printf("⚠️ Produto %d duplica outro produto do usuário %d; mantido como está.", p.ID, p.UserID)
+			duplicates++
+		case err != nil:
+			log.Printf("❌ Erro ao atualizar produto %d: %v", p.ID, err)
+			failed++
+		default:
+			updated++
+		}
+	}
+
+	log.Printf("✅ URLs canônicas: %d atualizadas, %d duplicadas, %d com erro.", updated, duplicates, failed)
+}
@@ -0,0 +1,126 @@
+// Comando reparse reprocessa os snapshots de HTML guardados com os
+// extractors atuais e corrige as linhas de price_history que mudaram.
+//
+//	go run ./cmd/reparse -since 720h -product 42 -dry-run
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"price-analyzer-backend/internal/data"
+	"price-analyzer-backend/internal/money"
+	"price-analyzer-backend/internal/snapshot"
+	"price-analyzer-backend/internal/web"
+)
+
+func main() {
+	productID := flag.Int("product", 0, "reprocessa só este produto (0 = todos)")
+	since := flag.Duration("since", 30*24*time.Hour, "janela de histórico a reprocessar")
+	dryRun := flag.Bool("dry-run", false, "só mostra o que mudaria, sem gravar")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Aviso: Arquivo .env não encontrado, usando variáveis de ambiente do OS.")
+	}
+
+	// Sem recarregamento: o reprocessamento usa as definições do início.
+	storesCfg := web.StoresConfigFromEnv()
+	storesCfg.ReloadInterval = 0
+	if err := web.ConfigureStores(storesCfg); err != nil {
+		log.Fatal("Erro ao carregar definições de lojas:", err)
+	}
+
+	store := snapshot.FromEnv()
+	if store == nil {
+		log.Fatal("SNAPSHOT_DIR não configurado; nada para reprocessar.")
+	}
+	snapshot.Configure(store)
+
+	data.ConnectDB()
+
+	samples, err := data.GetSnapshotSamples(*productID, time.Now().Add(-*since))
+	if err != nil {
+		log.Fatal("Erro ao buscar histórico:", err)
+	}
+	log.Printf("🔁 %d leituras com snapshot para reprocessar.", len(samples))
+
+	var missing, failed, corrected int
+	for _, s := range samples {
+		html, err := snapshot.Load(s.SnapshotHash)
+		if err != nil {
+			missing++
+			continue
+		}
+
+		r, err := web.ExtractHTML(html, s.URL)
+		if err != nil {
+			log.Printf("❌ Leitura %d: %v", s.ID, err)
+			failed++
+			continue
+		}
+		// Um snapshot que os extractors atuais não conseguem ler não corrige
+		// nada: apagar o preço gravado só perderia histórico bom.
+		if err := r.Problem(); err != nil {
+			log.Printf("❌ Leitura %d: %v", s.ID, err)
+			failed++
+			continue
+		}
+		if r.Price == 0 && amountOf(s.Price) > 0 {
+			log.Printf("❌ Leitura %d: sem preço no snapshot, mas %s gravado.", s.ID, amountOf(s.Price))
+			failed++
+			continue
+		}
+
+		sample := r.Sample()
+		if !changed(s.PricePoint, sample) {
+			continue
+		}
+
+		log.Printf("✏️ Leitura %d (produto %d, %s): %s -> %s",
+			s.ID, s.ProductID, s.ScrapedAt.Format("2006-01-02 15:04"), amountOf(s.Price), sample.Price)
+		corrected++
+		if *dryRun {
+			continue
+		}
+		if err := data.CorrectPriceSample(s.ID, sample); err != nil {
+			log.Printf("❌ Erro ao corrigir leitura %d: %v", s.ID, err)
+			failed++
+		}
+	}
+
+	log.Printf("✅ Reprocessamento concluído: %d corrigidas, %d sem snapshot, %d com erro.", corrected, missing, failed)
+}
+
+// changed compara a linha gravada com a nova leitura, campo a campo.
+func changed(old data.PricePoint, s data.PriceSample) bool {
+	count := 0
+	if old.InstallmentCount != nil {
+		count = *old.InstallmentCount
+	}
+	return amountOf(old.Price) != s.Price ||
+		amountOf(old.CashPrice) != s.CashPrice ||
+		amountOf(old.CardPrice) != s.CardPrice ||
+		count != s.InstallmentCount ||
+		amountOf(old.InstallmentPrice) != s.InstallmentPrice ||
+		amountOf(old.ListPrice) != s.ListPrice ||
+		old.Currency != currencyOrBRL(s.Currency) ||
+		old.Availability != s.Availability
+}
+
+func amountOf(a *money.Amount) money.Amount {
+	if a == nil {
+		return 0
+	}
+	return *a
+}
+
+func currencyOrBRL(c string) string {
+	if c == "" {
+		return "BRL"
+	}
+	return c
+}
@@ -0,0 +1,145 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"price-analyzer-backend/internal/data"
+	"price-analyzer-backend/internal/money"
+)
+
+const (
+	VerdictFakeDiscount     = "fake_discount"
+	VerdictRealDiscount     = "real_discount"
+	VerdictNoDiscount       = "no_discount"
+	VerdictInsufficientData = "insufficient_data"
+)
+
+const (
+	// Janela usada como preço de referência "normal" do produto.
+	baselineWindow = 90 * 24 * time.Hour
+	// Semanas anteriores à promoção, onde se procura a alta de preço.
+	runUpWindow = 30 * 24 * time.Hour
+
+	// Variações menores que isso são tratadas como ruído.
+	tolerance = 0.05
+	minPoints = 5
+)
+
+// DiscountAnalysis é o veredito sobre a queda de preço atual, com os dados
+// que o sustentam.
+type DiscountAnalysis struct {
+	Verdict string `json:"verdict"`
+	Message string `json:"message"`
+
+	CurrentPrice  money.Amount `json:"current_price"`
+	BaselinePrice money.Amount `json:"baseline_price"`
+	PeakPrice     money.Amount `json:"peak_price"`
+	PeakAt        time.Time    `json:"peak_at,omitempty"`
+	LowestPrice   money.Amount `json:"lowest_price"`
+
+	// Desconto real em relação à referência e o anunciado pela loja.
+	RealDiscountPercent       float64 `json:"real_discount_percent"`
+	AdvertisedDiscountPercent float64 `json:"advertised_discount_percent"`
+
+	Points []data.PricePoint `json:"points"`
+}
+
+func (a DiscountAnalysis) IsFake() bool {
+	return a.Verdict == VerdictFakeDiscount
+}
+
+// AnalyzeProduct carrega o histórico do produto e aplica Analyze.
+func AnalyzeProduct(productID int, userID int) (DiscountAnalysis, error) {
+	history, err := data.GetProductHistory(productID, userID)
+	if err != nil {
+		return DiscountAnalysis{}, err
+	}
+	return Analyze(history, time.Now()), nil
+}
+
+// Analyze procura o truque clássico de Black Friday: a loja sobe o preço nas
+// semanas anteriores à promoção e depois "desconta" de volta ao nível antigo.
+//
+// A referência é a mediana dos preços entre 90 e 30 dias atrás; a alta é o
+// maior preço dos últimos 30 dias. Se houve alta relevante e o preço atual
+// voltou para perto da referência, o desconto é considerado falso.
+func Analyze(history []data.PricePoint, now time.Time) DiscountAnalysis {
+	var points []data.PricePoint
+	for _, p := range history {
+		if p.Price != nil && *p.Price > 0 && !p.ScrapedAt.Before(now.Add(-baselineWindow)) {
+			points = append(points, p)
+		}
+	}
+
+	a := DiscountAnalysis{Verdict: VerdictInsufficientData, Points: points}
+	if len(points) == 0 {
+		a.Message = "Ainda não há histórico suficiente para avaliar o desconto."
+		return a
+	}
+
+	last := points[len(points)-1]
+	a.CurrentPrice = *last.Price
+	if last.DiscountPercent != nil {
+		a.AdvertisedDiscountPercent = *last.DiscountPercent
+	}
+
+	runUpStart := now.Add(-runUpWindow)
+	var baseline []money.Amount
+	a.LowestPrice = a.CurrentPrice
+	for _, p := range points[:len(points)-1] {
+		price := *p.Price
+		if price < a.LowestPrice {
+			a.LowestPrice = price
+		}
+		if p.ScrapedAt.Before(runUpStart) {
+			baseline = append(baseline, price)
+		} else if price > a.PeakPrice {
+			a.PeakPrice, a.PeakAt = price, p.ScrapedAt
+		}
+	}
+
+	if len(points) < minPoints || len(baseline) == 0 {
+		a.Message = "Ainda não há histórico suficiente para avaliar o desconto."
+		return a
+	}
+
+	a.BaselinePrice = median(baseline)
+	a.RealDiscountPercent = percentBelow(a.CurrentPrice, a.BaselinePrice)
+
+	raised := a.PeakPrice > a.BaselinePrice.MulFloat(1+tolerance)
+	droppedFromPeak := a.CurrentPrice < a.PeakPrice.MulFloat(1-tolerance)
+	backToBaseline := a.CurrentPrice >= a.BaselinePrice.MulFloat(1-tolerance)
+
+	switch {
+	case raised && droppedFromPeak && backToBaseline:
+		a.Verdict = VerdictFakeDiscount
+		a.Message = "O preço subiu nas últimas semanas e voltou ao nível anterior: o desconto não é real."
+	case a.CurrentPrice < a.BaselinePrice.MulFloat(1-tolerance):
+		a.Verdict = VerdictRealDiscount
+		a.Message = "O preço está abaixo do praticado nos últimos meses."
+	default:
+		a.Verdict = VerdictNoDiscount
+		a.Message = "O preço está no mesmo nível dos últimos meses."
+	}
+
+	return a
+}
+
+func median(values []money.Amount) money.Amount {
+	sorted := append([]money.Amount(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func percentBelow(price, reference money.Amount) float64 {
+	if reference <= 0 || price >= reference {
+		return 0
+	}
+	return math.Round((1-price.Float64()/reference.Float64())*1000) / 10
+}
@@ -0,0 +1,65 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+
+	"price-analyzer-backend/internal/money"
+)
+
+const (
+	// Quedas maiores que isso em relação à mediana recente são suspeitas:
+	// pegar só a fração ou o valor da parcela costuma dar -80% ou mais.
+	maxDrop = 0.5
+	// Altas de mais que o dobro também.
+	maxRise = 1.0
+
+	minOutlierHistory = 3
+)
+
+// OutlierCheck é o resultado da comparação de uma nova leitura com as
+// anteriores.
+type OutlierCheck struct {
+	Suspicious    bool
+	Reason        string
+	Reference     money.Amount
+	ChangePercent float64
+}
+
+// CheckSample compara o novo preço com a mediana das leituras recentes.
+// Sem histórico suficiente, tudo é aceito.
+func CheckSample(recent []money.Amount, price money.Amount) OutlierCheck {
+	var valid []money.Amount
+	for _, p := range recent {
+		if p > 0 {
+			valid = append(valid, p)
+		}
+	}
+	if price <= 0 || len(valid) < minOutlierHistory {
+		return OutlierCheck{}
+	}
+
+	sort.Slice(valid, func(i, j int) bool { return valid[i] < valid[j] })
+	ref := median(valid)
+
+	change := price.Float64()/ref.Float64() - 1
+	c := OutlierCheck{Reference: ref, ChangePercent: math.Round(change*1000) / 10}
+
+	switch {
+	case change <= -maxDrop:
+		c.Suspicious = true
+		c.Reason = "queda brusca em relação ao histórico recente"
+	case change >= maxRise:
+		c.Suspicious = true
+		c.Reason = "alta brusca em relação ao histórico recente"
+	}
+	return c
+}
+
+// Confirms diz se duas leituras independentes concordam (diferença de até 2%).
+func Confirms(a, b money.Amount) bool {
+	if a <= 0 || b <= 0 {
+		return false
+	}
+	return math.Abs(a.Float64()/b.Float64()-1) <= 0.02
+}
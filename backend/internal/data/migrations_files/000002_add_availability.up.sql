@@ -0,0 +1,8 @@
+-- backend/migrations/000002_add_availability.up.sql
+
+-- Estado de estoque em cada amostra e o último conhecido no produto.
+-- Amostras sem estoque podem não ter preço, por isso price passa a aceitar NULL.
+ALTER TABLE price_history ADD COLUMN IF NOT EXISTS availability TEXT NOT NULL DEFAULT 'unknown';
+ALTER TABLE price_history ALTER COLUMN price DROP NOT NULL;
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS availability TEXT NOT NULL DEFAULT 'unknown';
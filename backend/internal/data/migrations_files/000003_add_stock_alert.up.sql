@@ -0,0 +1,4 @@
+-- backend/migrations/000003_add_stock_alert.up.sql
+
+-- Alerta de volta ao estoque, opcional por produto.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS stock_alert BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,10 @@
+-- backend/migrations/000004_add_price_types.up.sql
+
+-- Séries separadas para preço à vista (Pix/boleto), no cartão e parcelado.
+ALTER TABLE price_history ADD COLUMN IF NOT EXISTS cash_price DECIMAL(10, 2);
+ALTER TABLE price_history ADD COLUMN IF NOT EXISTS card_price DECIMAL(10, 2);
+ALTER TABLE price_history ADD COLUMN IF NOT EXISTS installment_count INT;
+ALTER TABLE price_history ADD COLUMN IF NOT EXISTS installment_price DECIMAL(10, 2);
+
+-- Qual preço o alvo do usuário compara: default, cash ou card.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS target_price_type TEXT NOT NULL DEFAULT 'default';
@@ -0,0 +1,4 @@
+-- backend/migrations/000005_add_list_price.up.sql
+
+-- Preço "de" anunciado pela loja, para comparar o desconto com o histórico.
+ALTER TABLE price_history ADD COLUMN IF NOT EXISTS list_price DECIMAL(10, 2);
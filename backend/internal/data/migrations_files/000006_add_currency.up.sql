@@ -0,0 +1,8 @@
+-- backend/migrations/000006_add_currency.up.sql
+
+-- Moeda detectada em cada leitura; registros antigos são todos em reais.
+ALTER TABLE price_history ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'BRL';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'BRL';
+
+-- Moeda em que o usuário prefere ver os preços.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS display_currency CHAR(3) NOT NULL DEFAULT 'BRL';
@@ -0,0 +1,21 @@
+-- backend/migrations/000007_create_scrape_attempts.up.sql
+
+-- Cada tentativa de coleta, com sucesso ou não.
+CREATE TABLE IF NOT EXISTS scrape_attempts (
+    id SERIAL PRIMARY KEY,
+    product_id INT REFERENCES products(id) ON DELETE CASCADE,
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    duration_ms INT NOT NULL DEFAULT 0,
+    status TEXT NOT NULL,
+    error_kind TEXT,
+    error_message TEXT,
+    http_status INT
+);
+
+CREATE INDEX IF NOT EXISTS idx_scrape_attempts_product ON scrape_attempts(product_id, started_at DESC);
+
+-- Resumo da última coleta bem-sucedida e do último erro, para a API.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS last_success_at TIMESTAMP;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS last_error_at TIMESTAMP;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS last_error_kind TEXT NOT NULL DEFAULT '';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS last_error TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,18 @@
+-- backend/migrations/000008_add_canonical_url.up.sql
+
+-- URL canônica do produto (sem rastreamento, links curtos resolvidos), para
+-- impedir o mesmo item duas vezes na lista do usuário.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS canonical_url TEXT;
+
+UPDATE products SET canonical_url = url WHERE canonical_url IS NULL;
+
+-- Duplicatas que já existam ficam distintas para não barrar o índice.
+UPDATE products p SET canonical_url = p.url || '#dup-' || p.id
+WHERE EXISTS (
+    SELECT 1 FROM products o
+    WHERE o.user_id = p.user_id AND o.canonical_url = p.canonical_url AND o.id < p.id
+);
+
+ALTER TABLE products ALTER COLUMN canonical_url SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_user_canonical ON products(user_id, canonical_url);
@@ -0,0 +1,17 @@
+-- backend/migrations/000009_create_suspicious_samples.up.sql
+
+-- Leituras que destoaram do histórico e aguardam confirmação por uma nova
+-- coleta antes de ir para price_history. Ficam guardadas para revisão.
+CREATE TABLE IF NOT EXISTS suspicious_samples (
+    id SERIAL PRIMARY KEY,
+    product_id INT REFERENCES products(id) ON DELETE CASCADE,
+    price DECIMAL(10, 2) NOT NULL,
+    reference_price DECIMAL(10, 2) NOT NULL,
+    change_percent DOUBLE PRECISION NOT NULL,
+    reason TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    scraped_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_suspicious_product ON suspicious_samples(product_id, scraped_at DESC);
@@ -0,0 +1,6 @@
+-- backend/migrations/000010_add_snapshot_hash.up.sql
+
+-- Referência ao HTML bruto (store de snapshots) de onde cada leitura saiu,
+-- para poder reprocessar o histórico quando um extractor for corrigido.
+ALTER TABLE price_history ADD COLUMN IF NOT EXISTS snapshot_hash TEXT;
+CREATE INDEX IF NOT EXISTS idx_price_history_snapshot ON price_history(snapshot_hash) WHERE snapshot_hash IS NOT NULL;
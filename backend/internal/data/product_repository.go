@@ -4,8 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
+
+	"price-analyzer-backend/internal/money"
 )
 
 type User struct {
@@ -15,6 +18,7 @@ type User struct {
 	Name     string `db:"name" json:"name"`
 	AvatarURL string `db:"avatar_url" json:"avatar_url"`
 	TelegramChatID string `db:"telegram_chat_id" json:"telegram_chat_id"`
+	DisplayCurrency string `db:"display_currency" json:"display_currency"`
 }
 
 type Product struct {
@@ -22,17 +26,54 @@ type Product struct {
 	UserID         int          `db:"user_id" json:"user_id"`
 	Name           string       `db:"name" json:"name"`
 	URL            string       `db:"url" json:"url"`
+	CanonicalURL   string       `db:"canonical_url" json:"canonical_url"`
 	ImageURL       string       `db:"image_url" json:"image_url"`
-	CurrentPrice   float64      `db:"current_price" json:"price"`
+	CurrentPrice   money.Amount `db:"current_price" json:"price"`
+	Currency       string       `db:"currency" json:"currency"`
 	CreatedAt      time.Time    `db:"created_at" json:"created_at"`
-	TargetPrice    float64      `db:"target_price" json:"target_price"`
+	TargetPrice    money.Amount `db:"target_price" json:"target_price"`
+	TargetType     string       `db:"target_price_type" json:"target_price_type"`
+	Availability   string       `db:"availability" json:"availability"`
+	StockAlert     bool         `db:"stock_alert" json:"stock_alert"`
 	LastAlertAt    sql.NullTime `db:"last_alert_at" json:"-"`
 	TelegramChatID string       `db:"telegram_chat_id" json:"-"`
+
+	// Última coleta bem-sucedida e último erro, para explicar preços parados.
+	LastSuccessAt *time.Time `db:"last_success_at" json:"last_success_at"`
+	LastErrorAt   *time.Time `db:"last_error_at" json:"last_error_at"`
+	LastErrorKind string     `db:"last_error_kind" json:"last_error_kind"`
+	LastError     string     `db:"last_error" json:"last_error"`
+
+	// Preço convertido para a moeda preferida do usuário.
+	DisplayPrice    money.Amount `db:"-" json:"display_price,omitempty"`
+	DisplayCurrency string       `db:"display_currency" json:"display_currency,omitempty"`
 }
 
 type PricePoint struct {
-	Price     float64   `db:"price" json:"price"`
-	ScrapedAt time.Time `db:"scraped_at" json:"date"`
+	Price            *money.Amount `db:"price" json:"price"`
+	CashPrice        *money.Amount `db:"cash_price" json:"cash_price"`
+	CardPrice        *money.Amount `db:"card_price" json:"card_price"`
+	InstallmentCount *int          `db:"installment_count" json:"installment_count"`
+	InstallmentPrice *money.Amount `db:"installment_price" json:"installment_price"`
+	ListPrice        *money.Amount `db:"list_price" json:"list_price"`
+	Currency         string        `db:"currency" json:"currency"`
+	DiscountPercent  *float64      `db:"discount_percent" json:"discount_percent"`
+	Availability     string        `db:"availability" json:"availability"`
+	ScrapedAt        time.Time     `db:"scraped_at" json:"date"`
+}
+
+// PriceSample é uma leitura do scraper pronta para ir ao histórico.
+// Valores zerados são gravados como NULL.
+type PriceSample struct {
+	Price            money.Amount
+	CashPrice        money.Amount
+	CardPrice        money.Amount
+	InstallmentCount int
+	InstallmentPrice money.Amount
+	ListPrice        money.Amount
+	Currency         string
+	Availability     string
+	SnapshotHash     string
 }
 
 const productCacheTTL = 10 * time.Minute
@@ -65,19 +106,61 @@ func GetOrCreateUser(googleID, email, name, avatarURL string) (User, error) {
 
 func GetUserByID(userID int) (User, error) {
     var user User
-    query := `SELECT id, google_id, email, name, avatar_url, telegram_chat_id FROM users WHERE id = $1`
+    query := `SELECT id, google_id, email, name, avatar_url, telegram_chat_id, display_currency FROM users WHERE id = $1`
     err := DB.Get(&user, query, userID)
     return user, err
 }
 
+// ErrDuplicateProduct indica que o usuário já monitora o produto.
+var ErrDuplicateProduct = errors.New("produto já cadastrado")
+
+// ProductURL é o par URL informada / URL canônica de um produto.
+type ProductURL struct {
+	ID           int    `db:"id"`
+	UserID       int    `db:"user_id"`
+	URL          string `db:"url"`
+	CanonicalURL string `db:"canonical_url"`
+}
+
+func GetAllProductURLs() ([]ProductURL, error) {
+	urls := []ProductURL{}
+	err := DB.Select(&urls, `SELECT id, user_id, url, canonical_url FROM products ORDER BY id`)
+	return urls, err
+}
+
+// UpdateCanonicalURL grava a URL canônica do produto. Se o usuário já tiver
+// outro produto com a mesma URL, nada muda e ErrDuplicateProduct é devolvido.
+func UpdateCanonicalURL(productID int, canonicalURL string) error {
+	res, err := DB.Exec(`UPDATE products p SET canonical_url = $1
+		WHERE p.id = $2 AND NOT EXISTS (
+			SELECT 1 FROM products o WHERE o.user_id = p.user_id AND o.canonical_url = $1 AND o.id <> p.id
+		)`, canonicalURL, productID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrDuplicateProduct
+	}
+	return nil
+}
+
 func CreateProduct(p Product) (int, error) {
 	var id int
 	query := `
-		INSERT INTO products (name, url, image_url, current_price, user_id) 
-		VALUES ($1, $2, $3, $4, $5) 
+		INSERT INTO products (name, url, canonical_url, image_url, current_price, user_id, availability, currency) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
+		ON CONFLICT (user_id, canonical_url) DO NOTHING
 		RETURNING id`
 
-	err := DB.QueryRow(query, p.Name, p.URL, p.ImageURL, p.CurrentPrice, p.UserID).Scan(&id)
+	canonical := p.CanonicalURL
+	if canonical == "" {
+		canonical = p.URL
+	}
+
+	err := DB.QueryRow(query, p.Name, p.URL, canonical, p.ImageURL, p.CurrentPrice, p.UserID, p.Availability, currencyOrDefault(p.Currency)).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrDuplicateProduct
+	}
 	
 	if err == nil {
 		InvalidateUserCache(p.UserID)
@@ -100,7 +183,8 @@ func GetAllProducts(userID int) ([]Product, error) {
 	}
 
 	var products []Product
-	query := `SELECT id, user_id, name, url, image_url, current_price, created_at, target_price, last_alert_at
+	query := `SELECT id, user_id, name, url, canonical_url, image_url, current_price, currency, created_at, target_price, target_price_type, last_alert_at, availability, stock_alert,
+			         last_success_at, last_error_at, last_error_kind, last_error
 			  FROM products 
 			  WHERE user_id = $1
 			  ORDER BY created_at DESC`
@@ -124,9 +208,9 @@ func GetAllProductsForWorker() ([]Product, error) {
 	products := []Product{}
 
 	query := `
-		SELECT p.id, p.user_id, p.name, p.url, p.image_url, p.current_price, 
-               p.created_at, p.target_price, p.last_alert_at,
-               u.telegram_chat_id
+		SELECT p.id, p.user_id, p.name, p.url, p.canonical_url, p.image_url, p.current_price, p.currency,
+               p.created_at, p.target_price, p.target_price_type, p.last_alert_at, p.availability, p.stock_alert,
+               u.telegram_chat_id, u.display_currency
 		FROM products p
         JOIN users u ON p.user_id = u.id
 		ORDER BY p.created_at DESC`
@@ -135,19 +219,31 @@ func GetAllProductsForWorker() ([]Product, error) {
 	return products, err
 }
 
-func UpdatePrice(productID int, newPrice float64) error {
+// UpdatePrice grava uma amostra no histórico. Um preço zerado (produto sem
+// estoque, por exemplo) entra como NULL e não sobrescreve o preço atual.
+func UpdatePrice(productID int, sample PriceSample) error {
 	tx, err := DB.Begin()
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.Exec("INSERT INTO price_history (product_id, price) VALUES ($1, $2)", productID, newPrice)
+	price := nullPrice(sample.Price)
+
+	_, err = tx.Exec(`INSERT INTO price_history 
+		(product_id, price, cash_price, card_price, installment_count, installment_price, list_price, currency, availability, snapshot_hash) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		productID, price, nullPrice(sample.CashPrice), nullPrice(sample.CardPrice),
+		sql.NullInt64{Int64: int64(sample.InstallmentCount), Valid: sample.InstallmentCount > 0},
+		nullPrice(sample.InstallmentPrice), nullPrice(sample.ListPrice), currencyOrDefault(sample.Currency), sample.Availability,
+		nullString(sample.SnapshotHash))
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	_, err = tx.Exec("UPDATE products SET current_price = $1, updated_at = NOW() WHERE id = $2", newPrice, productID)
+	_, err = tx.Exec(`UPDATE products 
+		SET current_price = COALESCE($1, current_price), currency = $2, availability = $3, updated_at = NOW() 
+		WHERE id = $4`, price, currencyOrDefault(sample.Currency), sample.Availability, productID)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -160,7 +256,11 @@ func GetProductHistory(productID int, userID int) ([]PricePoint, error) {
 	history := []PricePoint{}
 	
 	query := `
-		SELECT ph.price, ph.scraped_at 
+		SELECT ph.price, ph.cash_price, ph.card_price, ph.installment_count, ph.installment_price,
+		       ph.list_price, ph.currency,
+		       CASE WHEN ph.list_price > ph.price
+		            THEN ROUND((1 - ph.price / ph.list_price) * 100, 1)::float8 END AS discount_percent,
+		       ph.availability, ph.scraped_at 
 		FROM price_history ph
 		JOIN products p ON ph.product_id = p.id
 		WHERE ph.product_id = $1 AND p.user_id = $2
@@ -170,9 +270,36 @@ func GetProductHistory(productID int, userID int) ([]PricePoint, error) {
 	return history, err
 }
 
+// GetRecentPrices devolve os últimos preços válidos do histórico, do mais
+// recente para o mais antigo. Uma mudança de preço confirmada vira a nova
+// referência: leituras anteriores a ela ficam de fora.
+func GetRecentPrices(productID int, limit int) ([]money.Amount, error) {
+	prices := []money.Amount{}
+	query := `
+		SELECT price FROM price_history
+		WHERE product_id = $1 AND price IS NOT NULL AND scraped_at > NOW() - INTERVAL '30 days'
+		  AND scraped_at > COALESCE((
+			SELECT MAX(resolved_at) FROM suspicious_samples
+			WHERE product_id = $1 AND status = 'confirmed'
+		  ), '-infinity')
+		ORDER BY scraped_at DESC
+		LIMIT $2`
+	err := DB.Select(&prices, query, productID, limit)
+	return prices, err
+}
+
+func GetProductByCanonicalURL(userID int, canonicalURL string) (Product, error) {
+	var p Product
+	query := `SELECT id, user_id, name, url, canonical_url, image_url, current_price, created_at, target_price 
+			  FROM products WHERE user_id = $1 AND canonical_url = $2`
+	err := DB.Get(&p, query, userID, canonicalURL)
+	return p, err
+}
+
 func GetProductByID(id int, userID int) (Product, error) {
 	var p Product
-	query := `SELECT id, user_id, name, url, image_url, current_price, created_at, target_price 
+	query := `SELECT id, user_id, name, url, canonical_url, image_url, current_price, created_at, target_price, target_price_type, availability, stock_alert, currency,
+			         last_success_at, last_error_at, last_error_kind, last_error 
 			  FROM products WHERE id = $1 AND user_id = $2`
 	err := DB.Get(&p, query, id, userID)
 	return p, err
@@ -183,9 +310,16 @@ func UpdateLastAlert(productID int) error {
 	return err
 }
 
-func UpdateTargetPrice(productID int, userID int, targetPrice float64) error {
-	query := `UPDATE products SET target_price = $1, last_alert_at = NULL WHERE id = $2 AND user_id = $3`
-	_, err := DB.Exec(query, targetPrice, productID, userID)
+// UpdateTargetPrice altera o preço-alvo e o tipo de preço comparado; um
+// argumento nil mantém o valor gravado.
+func UpdateTargetPrice(productID int, userID int, targetPrice *money.Amount, priceType *string) error {
+	query := `
+		UPDATE products
+		SET target_price = COALESCE($1, target_price),
+			target_price_type = COALESCE($2, target_price_type),
+			last_alert_at = NULL
+		WHERE id = $3 AND user_id = $4`
+	_, err := DB.Exec(query, targetPrice, priceType, productID, userID)
 	
 	if err == nil {
 		InvalidateUserCache(userID)
@@ -193,6 +327,16 @@ func UpdateTargetPrice(productID int, userID int, targetPrice float64) error {
 	return err
 }
 
+func UpdateStockAlert(productID int, userID int, enabled bool) error {
+	query := `UPDATE products SET stock_alert = $1, last_alert_at = NULL WHERE id = $2 AND user_id = $3`
+	_, err := DB.Exec(query, enabled, productID, userID)
+
+	if err == nil {
+		InvalidateUserCache(userID)
+	}
+	return err
+}
+
 func DeleteProduct(productID int, userID int) error {
 	_, err := DB.Exec("DELETE FROM products WHERE id = $1 AND user_id = $2", productID, userID)
 	
@@ -202,6 +346,21 @@ func DeleteProduct(productID int, userID int) error {
 	return err
 }
 
+func currencyOrDefault(code string) string {
+	if code == "" {
+		return "BRL"
+	}
+	return code
+}
+
+// nullPrice grava valores não positivos como NULL.
+func nullPrice(v money.Amount) interface{} {
+	if v <= 0 {
+		return nil
+	}
+	return v
+}
+
 func InvalidateUserCache(userID int) {
 	if RDB != nil {
 		key := fmt.Sprintf("products:user:%d", userID)
@@ -212,4 +371,9 @@ func InvalidateUserCache(userID int) {
 func UpdateUserTelegram(userID int, chatID string) error {
     _, err := DB.Exec("UPDATE users SET telegram_chat_id = $1 WHERE id = $2", chatID, userID)
     return err
-}
\ No newline at end of file
+}
+
+func UpdateUserCurrency(userID int, currency string) error {
+	_, err := DB.Exec("UPDATE users SET display_currency = $1 WHERE id = $2", currency, userID)
+	return err
+}
@@ -0,0 +1,83 @@
+package data
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	AttemptSuccess = "success"
+	AttemptError   = "error"
+)
+
+type ScrapeAttempt struct {
+	ID           int            `db:"id" json:"id"`
+	ProductID    int            `db:"product_id" json:"product_id"`
+	StartedAt    time.Time      `db:"started_at" json:"started_at"`
+	DurationMS   int            `db:"duration_ms" json:"duration_ms"`
+	Status       string         `db:"status" json:"status"`
+	ErrorKind    sql.NullString `db:"error_kind" json:"-"`
+	ErrorMessage sql.NullString `db:"error_message" json:"-"`
+	HTTPStatus   sql.NullInt64  `db:"http_status" json:"-"`
+
+	Kind    string `db:"-" json:"error_kind,omitempty"`
+	Message string `db:"-" json:"error,omitempty"`
+	Code    int    `db:"-" json:"http_status,omitempty"`
+}
+
+// RecordScrapeAttempt grava a tentativa e atualiza o resumo no produto.
+func RecordScrapeAttempt(a ScrapeAttempt) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO scrape_attempts 
+		(product_id, started_at, duration_ms, status, error_kind, error_message, http_status) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		a.ProductID, a.StartedAt, a.DurationMS, a.Status,
+		nullString(a.Kind), nullString(a.Message), sql.NullInt64{Int64: int64(a.Code), Valid: a.Code > 0})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if a.Status == AttemptSuccess {
+		_, err = tx.Exec("UPDATE products SET last_success_at = $1 WHERE id = $2", a.StartedAt, a.ProductID)
+	} else {
+		_, err = tx.Exec(`UPDATE products 
+			SET last_error_at = $1, last_error_kind = $2, last_error = $3 
+			WHERE id = $4`, a.StartedAt, a.Kind, a.Message, a.ProductID)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func GetScrapeAttempts(productID int, userID int, limit int) ([]ScrapeAttempt, error) {
+	attempts := []ScrapeAttempt{}
+
+	query := `
+		SELECT sa.id, sa.product_id, sa.started_at, sa.duration_ms, sa.status,
+		       sa.error_kind, sa.error_message, sa.http_status
+		FROM scrape_attempts sa
+		JOIN products p ON sa.product_id = p.id
+		WHERE sa.product_id = $1 AND p.user_id = $2
+		ORDER BY sa.started_at DESC
+		LIMIT $3`
+
+	err := DB.Select(&attempts, query, productID, userID, limit)
+	for i := range attempts {
+		attempts[i].Kind = attempts[i].ErrorKind.String
+		attempts[i].Message = attempts[i].ErrorMessage.String
+		attempts[i].Code = int(attempts[i].HTTPStatus.Int64)
+	}
+	return attempts, err
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,46 @@
+package data
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SnapshotSample é uma linha do histórico que guarda referência ao HTML de
+// origem, junto com a URL do produto para escolher o extractor.
+type SnapshotSample struct {
+	ID           int    `db:"id"`
+	ProductID    int    `db:"product_id"`
+	URL          string `db:"url"`
+	SnapshotHash string `db:"snapshot_hash"`
+	PricePoint
+}
+
+// GetSnapshotSamples lista as leituras com snapshot desde a data informada.
+// productID zero traz todos os produtos.
+func GetSnapshotSamples(productID int, since time.Time) ([]SnapshotSample, error) {
+	samples := []SnapshotSample{}
+	query := `
+		SELECT h.id, h.product_id, p.url, h.snapshot_hash, h.scraped_at,
+		       h.price, h.cash_price, h.card_price, h.installment_count, h.installment_price,
+		       h.list_price, h.currency, h.availability
+		FROM price_history h
+		JOIN products p ON p.id = h.product_id
+		WHERE h.snapshot_hash IS NOT NULL AND h.scraped_at >= $1 AND ($2 = 0 OR h.product_id = $2)
+		ORDER BY h.scraped_at`
+	err := DB.Select(&samples, query, since, productID)
+	return samples, err
+}
+
+// CorrectPriceSample regrava uma linha do histórico com a leitura refeita a
+// partir do snapshot. A data da coleta é preservada.
+func CorrectPriceSample(id int, sample PriceSample) error {
+	_, err := DB.Exec(`UPDATE price_history 
+		SET price = $1, cash_price = $2, card_price = $3, installment_count = $4, installment_price = $5,
+		    list_price = $6, currency = $7, availability = $8
+		WHERE id = $9`,
+		nullPrice(sample.Price), nullPrice(sample.CashPrice), nullPrice(sample.CardPrice),
+		sql.NullInt64{Int64: int64(sample.InstallmentCount), Valid: sample.InstallmentCount > 0},
+		nullPrice(sample.InstallmentPrice), nullPrice(sample.ListPrice), currencyOrDefault(sample.Currency),
+		sample.Availability, id)
+	return err
+}
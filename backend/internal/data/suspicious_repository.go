@@ -0,0 +1,64 @@
+package data
+
+import (
+	"time"
+
+	"price-analyzer-backend/internal/money"
+)
+
+const (
+	SuspiciousPending   = "pending"
+	SuspiciousConfirmed = "confirmed"
+	SuspiciousRejected  = "rejected"
+)
+
+type SuspiciousSample struct {
+	ID             int          `db:"id" json:"id"`
+	ProductID      int          `db:"product_id" json:"product_id"`
+	Price          money.Amount `db:"price" json:"price"`
+	ReferencePrice money.Amount `db:"reference_price" json:"reference_price"`
+	ChangePercent  float64      `db:"change_percent" json:"change_percent"`
+	Reason         string       `db:"reason" json:"reason"`
+	Status         string       `db:"status" json:"status"`
+	ScrapedAt      time.Time    `db:"scraped_at" json:"scraped_at"`
+	ResolvedAt     *time.Time   `db:"resolved_at" json:"resolved_at"`
+}
+
+func InsertSuspiciousSample(s SuspiciousSample) error {
+	query := `
+		INSERT INTO suspicious_samples (product_id, price, reference_price, change_percent, reason) 
+		VALUES ($1, $2, $3, $4, $5)`
+	_, err := DB.Exec(query, s.ProductID, s.Price, s.ReferencePrice, s.ChangePercent, s.Reason)
+	return err
+}
+
+// GetPendingSuspicious devolve a leitura suspeita mais recente ainda não
+// resolvida, ou sql.ErrNoRows.
+func GetPendingSuspicious(productID int) (SuspiciousSample, error) {
+	var s SuspiciousSample
+	query := `
+		SELECT id, product_id, price, reference_price, change_percent, reason, status, scraped_at, resolved_at
+		FROM suspicious_samples
+		WHERE product_id = $1 AND status = $2
+		ORDER BY scraped_at DESC
+		LIMIT 1`
+	err := DB.Get(&s, query, productID, SuspiciousPending)
+	return s, err
+}
+
+func ResolveSuspicious(id int, status string) error {
+	_, err := DB.Exec("UPDATE suspicious_samples SET status = $1, resolved_at = NOW() WHERE id = $2", status, id)
+	return err
+}
+
+func GetSuspiciousSamples(productID int, userID int) ([]SuspiciousSample, error) {
+	samples := []SuspiciousSample{}
+	query := `
+		SELECT s.id, s.product_id, s.price, s.reference_price, s.change_percent, s.reason, s.status, s.scraped_at, s.resolved_at
+		FROM suspicious_samples s
+		JOIN products p ON s.product_id = p.id
+		WHERE s.product_id = $1 AND p.user_id = $2
+		ORDER BY s.scraped_at DESC`
+	err := DB.Select(&samples, query, productID, userID)
+	return samples, err
+}
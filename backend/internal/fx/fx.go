@@ -0,0 +1,78 @@
+package fx
+
+import (
+	"strings"
+	"sync"
+
+	"price-analyzer-backend/internal/money"
+)
+
+// RateProvider devolve quantas unidades de `to` valem uma unidade de `from`.
+type RateProvider interface {
+	Rate(from, to string) (float64, error)
+}
+
+var (
+	mu       sync.RWMutex
+	provider RateProvider = defaultTable()
+)
+
+// SetProvider troca a fonte de câmbio usada por Convert.
+func SetProvider(p RateProvider) {
+	mu.Lock()
+	defer mu.Unlock()
+	provider = p
+}
+
+// Convert converte um valor entre moedas usando o provider configurado.
+func Convert(amount money.Amount, from, to string) (money.Amount, error) {
+	from, to = Normalize(from), Normalize(to)
+	if from == to || amount == 0 {
+		return amount, nil
+	}
+
+	mu.RLock()
+	p := provider
+	mu.RUnlock()
+
+	rate, err := p.Rate(from, to)
+	if err != nil {
+		return 0, err
+	}
+	return amount.MulFloat(rate), nil
+}
+
+// Normalize padroniza um código ISO 4217; vazio vira BRL, a moeda padrão
+// das lojas monitoradas.
+func Normalize(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return "BRL"
+	}
+	return code
+}
+
+var symbols = map[string]string{
+	"BRL": "R$",
+	"USD": "US$",
+	"EUR": "€",
+	"GBP": "£",
+	"ARS": "AR$",
+	"CLP": "CLP$",
+	"MXN": "MX$",
+	"JPY": "¥",
+}
+
+// Symbol devolve o símbolo usado nas mensagens, ou o próprio código.
+func Symbol(code string) string {
+	code = Normalize(code)
+	if s, ok := symbols[code]; ok {
+		return s
+	}
+	return code
+}
+
+// Format escreve o valor como "R$ 1234.56", no formato das mensagens do bot.
+func Format(amount money.Amount, code string) string {
+	return Symbol(code) + " " + amount.String()
+}
@@ -0,0 +1,85 @@
+package fx
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//go:embed rates.csv
+var defaultRates string
+
+// StaticProvider usa uma tabela local de câmbio, sem depender de rede. Cada
+// linha do CSV traz o código da moeda e quanto uma unidade dela vale na moeda
+// base da tabela (a primeira coluna do cabeçalho indica a base).
+type StaticProvider struct {
+	rates map[string]float64
+}
+
+// LoadStaticFile lê uma tabela de câmbio em CSV do disco.
+func LoadStaticFile(path string) (*StaticProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseStatic(f)
+}
+
+// ParseStatic lê linhas "moeda,valor" e ignora o cabeçalho e comentários (#).
+func ParseStatic(r io.Reader) (*StaticProvider, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &StaticProvider{rates: map[string]float64{}}
+	for i, rec := range records {
+		code := Normalize(rec[0])
+		value, err := strconv.ParseFloat(strings.TrimSpace(rec[1]), 64)
+		if err != nil {
+			if i == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("taxa inválida para %s: %w", code, err)
+		}
+		if value <= 0 {
+			return nil, fmt.Errorf("taxa inválida para %s: %v", code, value)
+		}
+		p.rates[code] = value
+	}
+
+	if len(p.rates) == 0 {
+		return nil, fmt.Errorf("tabela de câmbio vazia")
+	}
+	return p, nil
+}
+
+func (p *StaticProvider) Rate(from, to string) (float64, error) {
+	fromRate, ok := p.rates[Normalize(from)]
+	if !ok {
+		return 0, fmt.Errorf("moeda sem cotação: %s", from)
+	}
+	toRate, ok := p.rates[Normalize(to)]
+	if !ok {
+		return 0, fmt.Errorf("moeda sem cotação: %s", to)
+	}
+	return fromRate / toRate, nil
+}
+
+func defaultTable() RateProvider {
+	p, err := ParseStatic(strings.NewReader(defaultRates))
+	if err != nil {
+		panic("fx: tabela padrão inválida: " + err.Error())
+	}
+	return p
+}
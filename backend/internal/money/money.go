@@ -0,0 +1,187 @@
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amount é um valor monetário em centavos. Evita os erros de arredondamento
+// de float64 nas comparações com o preço-alvo e mapeia direto para as colunas
+// DECIMAL(10, 2) do banco.
+type Amount int64
+
+// FromFloat arredonda um float64 para o centavo mais próximo.
+func FromFloat(f float64) Amount {
+	return Amount(math.Round(f * 100))
+}
+
+// FromCents cria um valor a partir de centavos.
+func FromCents(c int64) Amount {
+	return Amount(c)
+}
+
+// Parse lê um decimal com ponto ("1234.5", "-0.99", "10") sem passar por
+// float64. Casas além dos centavos são arredondadas (meio para cima).
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("valor vazio")
+	}
+
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("valor inválido: %q", s)
+		}
+		a := FromFloat(f)
+		if neg {
+			a = -a
+		}
+		return a, nil
+	}
+
+	intPart, frac, _ := strings.Cut(s, ".")
+	if intPart == "" && frac == "" {
+		return 0, fmt.Errorf("valor inválido: %q", s)
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	units, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("valor inválido: %q", s)
+	}
+
+	var cents int64
+	for i := 0; i < 2; i++ {
+		cents *= 10
+		if i < len(frac) {
+			d := frac[i]
+			if d < '0' || d > '9' {
+				return 0, fmt.Errorf("valor inválido: %q", s)
+			}
+			cents += int64(d - '0')
+		}
+	}
+	if len(frac) > 2 {
+		for _, d := range frac[2:] {
+			if d < '0' || d > '9' {
+				return 0, fmt.Errorf("valor inválido: %q", s)
+			}
+		}
+		if frac[2] >= '5' {
+			cents++
+		}
+	}
+
+	a := Amount(units*100 + cents)
+	if neg {
+		a = -a
+	}
+	return a, nil
+}
+
+// Cents devolve o valor em centavos.
+func (a Amount) Cents() int64 {
+	return int64(a)
+}
+
+// Float64 é para cálculos de proporção (percentuais, câmbio), nunca para
+// comparar valores.
+func (a Amount) Float64() float64 {
+	return float64(a) / 100
+}
+
+// Mul multiplica por um inteiro, como no total de um parcelamento.
+func (a Amount) Mul(n int) Amount {
+	return a * Amount(n)
+}
+
+// MulFloat multiplica por um fator e arredonda para o centavo.
+func (a Amount) MulFloat(f float64) Amount {
+	return Amount(math.Round(float64(a) * f))
+}
+
+// String devolve o valor com duas casas e ponto decimal ("1234.56").
+func (a Amount) String() string {
+	sign := ""
+	c := int64(a)
+	if c < 0 {
+		sign = "-"
+		c = -c
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, c/100, c%100)
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON aceita números e strings, lendo o literal sem float64.
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	s := strings.TrimSpace(string(b))
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		var str string
+		if err := json.Unmarshal(b, &str); err != nil {
+			return err
+		}
+		s = str
+	}
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// Scan lê colunas NUMERIC/DECIMAL, que o driver entrega como texto.
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+		return nil
+	case string:
+		parsed, err := Parse(v)
+		if err != nil {
+			return err
+		}
+		*a = parsed
+	case []byte:
+		parsed, err := Parse(string(v))
+		if err != nil {
+			return err
+		}
+		*a = parsed
+	case int64:
+		*a = Amount(v * 100)
+	case float64:
+		*a = FromFloat(v)
+	default:
+		return fmt.Errorf("money: tipo não suportado %T", src)
+	}
+	return nil
+}
+
+// Value grava como texto decimal, que o Postgres converte para NUMERIC sem
+// perda de precisão.
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
@@ -0,0 +1,245 @@
+package money
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Locale diz quais separadores um texto de preço usa. O valor zero significa
+// "desconhecido": o parser decide pelo formato e marca os casos ambíguos.
+type Locale struct {
+	Decimal   rune
+	Thousands rune
+}
+
+var (
+	LocalePtBR    = Locale{Decimal: ',', Thousands: '.'}
+	LocaleEnUS    = Locale{Decimal: '.', Thousands: ','}
+	LocaleUnknown = Locale{}
+)
+
+// LocaleForCurrency devolve a convenção de separadores mais comum para a
+// moeda. Moedas desconhecidas resultam em LocaleUnknown.
+func LocaleForCurrency(code string) Locale {
+	switch strings.ToUpper(strings.TrimSpace(code)) {
+	case "BRL", "EUR", "ARS", "CLP", "COP", "UYU", "PYG":
+		return LocalePtBR
+	case "USD", "GBP", "MXN", "JPY", "CAD", "AUD":
+		return LocaleEnUS
+	}
+	return LocaleUnknown
+}
+
+// ParsedPrice é o resultado de ParseText.
+type ParsedPrice struct {
+	Amount Amount
+	// Currency é o código ISO deduzido do símbolo no texto, se houver.
+	Currency string
+	// Ambiguous indica que o texto admitia duas leituras (como "1.299", que
+	// pode ser mil duzentos e noventa e nove ou um vírgula dois) e que a
+	// escolha veio da dica de locale, ou de um palpite quando ela faltou.
+	Ambiguous bool
+}
+
+// Símbolos mais longos vêm antes: "R$" também aparece dentro de "AR$".
+var textSymbols = []struct {
+	symbol string
+	code   string
+}{
+	{"AR$", "ARS"},
+	{"MX$", "MXN"},
+	{"US$", "USD"},
+	{"U$", "USD"},
+	{"R$", "BRL"},
+	{"€", "EUR"},
+	{"£", "GBP"},
+	{"¥", "JPY"},
+	{"BRL", "BRL"},
+	{"USD", "USD"},
+	{"EUR", "EUR"},
+	{"GBP", "GBP"},
+}
+
+// ParseText lê o valor de um texto de preço como "R$ 1.299,90",
+// "US$1,299.99", "1 299,00 €" ou "12x de R$ 99". Quando há símbolo de
+// moeda, vale o número colado a ele, e não a quantidade de parcelas. Os
+// separadores de milhar e decimal são deduzidos do formato; quando ele é
+// ambíguo, decide a convenção da moeda do símbolo e, sem símbolo, hint.
+func ParseText(text string, hint Locale) (ParsedPrice, error) {
+	var res ParsedPrice
+
+	at, symbol := -1, ""
+	for _, s := range textSymbols {
+		if i := strings.Index(text, s.symbol); i >= 0 && (at < 0 || i < at) {
+			at, symbol, res.Currency = i, s.symbol, s.code
+		}
+	}
+	if loc := LocaleForCurrency(res.Currency); loc != LocaleUnknown {
+		hint = loc
+	}
+
+	token := ""
+	if at >= 0 {
+		after := strings.TrimLeftFunc(text[at+len(symbol):], unicode.IsSpace)
+		if after != "" && after[0] >= '0' && after[0] <= '9' {
+			token = numberToken(after)
+		} else {
+			token = lastNumberToken(text[:at])
+		}
+	}
+	if token == "" {
+		token = numberToken(text)
+	}
+	if token == "" {
+		return res, fmt.Errorf("nenhum valor encontrado em %q", text)
+	}
+
+	decimal, ambiguous, err := decimalSeparator(token, hint)
+	if err != nil {
+		return res, err
+	}
+	res.Ambiguous = ambiguous
+
+	var b strings.Builder
+	for _, r := range token {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == decimal:
+			b.WriteByte('.')
+		}
+	}
+
+	amount, err := Parse(b.String())
+	if err != nil {
+		return res, err
+	}
+	res.Amount = amount
+	return res, nil
+}
+
+// numberToken recorta a primeira sequência de dígitos e separadores,
+// normalizando espaços de agrupamento ("1 299,00") e removendo separadores
+// soltos no fim ("1.299,").
+func numberToken(text string) string {
+	runes := []rune(text)
+	start := -1
+	for i, r := range runes {
+		if r >= '0' && r <= '9' {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for i := start; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r >= '0' && r <= '9', r == '.', r == ',':
+			b.WriteRune(r)
+		case r == '\'' || r == '’' || unicode.IsSpace(r):
+			// espaço e apóstrofo só contam como separador entre dígitos
+			if i+1 < len(runes) && runes[i+1] >= '0' && runes[i+1] <= '9' && i > start {
+				b.WriteRune(' ')
+				continue
+			}
+			return strings.TrimRight(b.String(), ".,' ")
+		default:
+			return strings.TrimRight(b.String(), ".,' ")
+		}
+	}
+	return strings.TrimRight(b.String(), ".,' ")
+}
+
+var numberRunRe = regexp.MustCompile(`\d[\d.,'’\s]*`)
+
+// lastNumberToken recorta o último número do texto, para símbolos escritos
+// depois do valor ("1 299,00 €").
+func lastNumberToken(text string) string {
+	runs := numberRunRe.FindAllString(text, -1)
+	if len(runs) == 0 {
+		return ""
+	}
+	return numberToken(runs[len(runs)-1])
+}
+
+// decimalSeparator descobre qual separador (se algum) marca os centavos.
+func decimalSeparator(token string, hint Locale) (rune, bool, error) {
+	lastDot := strings.LastIndex(token, ".")
+	lastComma := strings.LastIndex(token, ",")
+
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		// Com os dois presentes, o último é o decimal: "1.299,90" / "1,299.90".
+		decimal, thousands := '.', ','
+		if lastComma > lastDot {
+			decimal, thousands = ',', '.'
+		}
+		intPart := token[:strings.LastIndex(token, string(decimal))]
+		if strings.ContainsRune(intPart, decimal) || !validGrouping(intPart, thousands) {
+			return 0, false, fmt.Errorf("separadores inconsistentes em %q", token)
+		}
+		return decimal, false, nil
+
+	case lastDot < 0 && lastComma < 0:
+		if strings.Contains(token, " ") && !validGrouping(token, ' ') {
+			return 0, false, fmt.Errorf("agrupamento inválido em %q", token)
+		}
+		return 0, false, nil
+	}
+
+	sep := '.'
+	if lastComma >= 0 {
+		sep = ','
+	}
+	last := strings.LastIndex(token, string(sep))
+
+	// Repetido, só pode ser milhar: "1.299.999".
+	if strings.Count(token, string(sep)) > 1 {
+		if !validGrouping(token, sep) {
+			return 0, false, fmt.Errorf("agrupamento inválido em %q", token)
+		}
+		return 0, false, nil
+	}
+
+	// Um único separador seguido de algo diferente de três dígitos é decimal:
+	// "12,9", "1299.99", "0.5".
+	digitsAfter := len(token) - last - 1
+	if digitsAfter != 3 || strings.Contains(token[:last], " ") {
+		return sep, false, nil
+	}
+
+	// "1.299" / "1,299": a dica de locale decide. Sem dica, preços com três
+	// casas decimais são raros, então lemos como milhar.
+	if !validGrouping(token, sep) {
+		return sep, false, nil
+	}
+	if sep == hint.Decimal {
+		return sep, true, nil
+	}
+	return 0, true, nil
+}
+
+// validGrouping confere que os grupos de milhar têm três dígitos e o
+// primeiro tem de um a três.
+func validGrouping(s string, sep rune) bool {
+	s = strings.ReplaceAll(s, " ", string(sep))
+	groups := strings.Split(s, string(sep))
+	if len(groups) == 1 {
+		return true
+	}
+	if len(groups[0]) == 0 || len(groups[0]) > 3 {
+		return false
+	}
+	for _, g := range groups[1:] {
+		if len(g) != 3 {
+			return false
+		}
+	}
+	return true
+}
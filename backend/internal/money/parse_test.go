@@ -0,0 +1,81 @@
+package money
+
+import "testing"
+
+func TestParseText(t *testing.T) {
+	cases := []struct {
+		name      string
+		text      string
+		hint      Locale
+		want      Amount
+		currency  string
+		ambiguous bool
+	}{
+		// Mercado Livre, Amazon, Kabum e Magalu.
+		{"brl completo", "R$ 1.299,90", LocaleUnknown, 129990, "BRL", false},
+		{"brl sem espaço", "R$1.299,90", LocaleUnknown, 129990, "BRL", false},
+		{"brl nbsp", "R$ 1.299,90", LocaleUnknown, 129990, "BRL", false},
+		{"brl sem centavos", "R$ 89", LocaleUnknown, 8900, "BRL", false},
+		{"brl só centavos", "R$ 0,99", LocaleUnknown, 99, "BRL", false},
+		{"brl milhões", "R$ 1.299.999,00", LocaleUnknown, 129999900, "BRL", false},
+		{"brl milhar ambíguo", "R$ 1.299", LocaleUnknown, 129900, "BRL", true},
+		{"amazon offscreen", "R$ 2.499,00", LocaleUnknown, 249900, "BRL", false},
+		{"kabum pix", "R$ 3.599,99 à vista no PIX", LocaleUnknown, 359999, "BRL", false},
+		{"texto antes do símbolo", "Por apenas R$ 49,90", LocaleUnknown, 4990, "BRL", false},
+
+		// Parcelamento: vale o valor ao lado do símbolo, não as parcelas.
+		{"parcelas", "12x de R$ 99,90", LocaleUnknown, 9990, "BRL", false},
+		{"parcelas sem juros", "em 10x R$ 129,99 sem juros", LocaleUnknown, 12999, "BRL", false},
+		{"parcelas sem 'de'", "ou 12x R$ 8,25", LocaleUnknown, 825, "BRL", false},
+
+		// Outras moedas e símbolos compostos.
+		{"usd", "US$ 1,299.99", LocaleUnknown, 129999, "USD", false},
+		{"usd colado", "US$1,299.99", LocaleUnknown, 129999, "USD", false},
+		{"u$", "U$ 49.90", LocaleUnknown, 4990, "USD", false},
+		{"ars", "AR$ 1.000", LocaleUnknown, 100000, "ARS", true},
+		{"ars com centavos", "AR$ 15.499,50", LocaleUnknown, 1549950, "ARS", false},
+		{"mxn", "MX$ 2,150.00", LocaleUnknown, 215000, "MXN", false},
+		{"eur depois", "1 299,00 €", LocaleUnknown, 129900, "EUR", false},
+		{"eur parcelas depois", "3x 33,30 €", LocaleUnknown, 3330, "EUR", false},
+		{"gbp", "£24.99", LocaleUnknown, 2499, "GBP", false},
+		{"código iso", "BRL 59,90", LocaleUnknown, 5990, "BRL", false},
+
+		// O símbolo vence a dica do chamador.
+		{"símbolo vence dica", "R$ 1.299", LocaleEnUS, 129900, "BRL", true},
+		{"usd vence dica", "US$ 1,299", LocalePtBR, 129900, "USD", true},
+
+		// Sem símbolo, a dica decide o caso ambíguo.
+		{"sem símbolo pt", "1.299", LocalePtBR, 129900, "", true},
+		{"sem símbolo en", "1.299", LocaleEnUS, 130, "", true},
+		{"sem símbolo decimal", "1299.99", LocaleUnknown, 129999, "", false},
+		{"agrupamento por espaço", "1 299,00", LocaleUnknown, 129900, "", false},
+		{"separador solto", "1.299,", LocalePtBR, 129900, "", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := ParseText(c.text, c.hint)
+			if err != nil {
+				t.Fatalf("ParseText(%q): %v", c.text, err)
+			}
+			if got.Amount != c.want || got.Currency != c.currency || got.Ambiguous != c.ambiguous {
+				t.Errorf("ParseText(%q) = %v %q ambíguo=%v, queria %v %q ambíguo=%v",
+					c.text, got.Amount, got.Currency, got.Ambiguous, c.want, c.currency, c.ambiguous)
+			}
+		})
+	}
+}
+
+func TestParseTextErrors(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"Indisponível",
+		"R$ --",
+		"1.29.9",
+		"1,299.99.00",
+	} {
+		if got, err := ParseText(text, LocaleUnknown); err == nil {
+			t.Errorf("ParseText(%q) = %v, queria erro", text, got.Amount)
+		}
+	}
+}
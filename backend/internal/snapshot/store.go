@@ -0,0 +1,214 @@
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrDisabled indica que nenhum diretório de snapshots foi configurado.
+var ErrDisabled = errors.New("armazenamento de snapshots desativado")
+
+// Store guarda o HTML bruto das páginas coletadas, comprimido e endereçado
+// pelo SHA-256 do conteúdo: a mesma página salva duas vezes ocupa um arquivo.
+type Store struct {
+	Dir      string
+	MaxAge   time.Duration
+	MaxBytes int64
+
+	mu sync.Mutex
+}
+
+var (
+	defaultMu    sync.RWMutex
+	defaultStore *Store
+)
+
+// Configure define o store usado por Save e Load. Dir vazio desativa.
+func Configure(s *Store) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultStore = s
+}
+
+// FromEnv monta o store a partir de SNAPSHOT_DIR, SNAPSHOT_MAX_AGE e
+// SNAPSHOT_MAX_BYTES. Sem SNAPSHOT_DIR, devolve nil.
+func FromEnv() *Store {
+	dir := os.Getenv("SNAPSHOT_DIR")
+	if dir == "" {
+		return nil
+	}
+
+	s := &Store{Dir: dir, MaxAge: 30 * 24 * time.Hour, MaxBytes: 2 << 30}
+	if d, err := time.ParseDuration(os.Getenv("SNAPSHOT_MAX_AGE")); err == nil {
+		s.MaxAge = d
+	}
+	if n, err := strconv.ParseInt(os.Getenv("SNAPSHOT_MAX_BYTES"), 10, 64); err == nil {
+		s.MaxBytes = n
+	}
+	return s
+}
+
+func current() *Store {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultStore
+}
+
+// Save grava no store padrão e devolve o hash do conteúdo.
+func Save(html []byte) (string, error) {
+	s := current()
+	if s == nil {
+		return "", ErrDisabled
+	}
+	return s.Save(html)
+}
+
+// Load lê do store padrão.
+func Load(hash string) ([]byte, error) {
+	s := current()
+	if s == nil {
+		return nil, ErrDisabled
+	}
+	return s.Load(hash)
+}
+
+func (s *Store) path(hash string) string {
+	return filepath.Join(s.Dir, hash[:2], hash+".html.gz")
+}
+
+func (s *Store) Save(html []byte) (string, error) {
+	sum := sha256.Sum256(html)
+	hash := hex.EncodeToString(sum[:])
+	path := s.path(hash)
+
+	if _, err := os.Stat(path); err == nil {
+		// Já existe: só renova a data para a retenção.
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		return hash, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(html); err != nil {
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return hash, nil
+}
+
+func (s *Store) Load(hash string) ([]byte, error) {
+	if len(hash) < 2 {
+		return nil, fmt.Errorf("hash de snapshot inválido: %q", hash)
+	}
+	f, err := os.Open(s.path(hash))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	return io.ReadAll(gz)
+}
+
+// Prune apaga snapshots mais velhos que MaxAge e, se o total ainda passar de
+// MaxBytes, os mais antigos até caber.
+func (s *Store) Prune() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type entry struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var (
+		entries []entry
+		total   int64
+		removed int
+	)
+
+	err := filepath.WalkDir(s.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if s.MaxAge > 0 && time.Since(info.ModTime()) > s.MaxAge {
+			if os.Remove(path) == nil {
+				removed++
+			}
+			return nil
+		}
+		entries = append(entries, entry{path, info.Size(), info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+
+	if s.MaxBytes > 0 && total > s.MaxBytes {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.Before(entries[j].modTime) })
+		for _, e := range entries {
+			if total <= s.MaxBytes {
+				break
+			}
+			if os.Remove(e.path) == nil {
+				total -= e.size
+				removed++
+			}
+		}
+	}
+	return removed, nil
+}
+
+// StartJanitor roda Prune periodicamente no store padrão.
+func StartJanitor(interval time.Duration) {
+	go func() {
+		for {
+			if s := current(); s != nil {
+				if n, err := s.Prune(); err != nil {
+					log.Println("⚠️ Erro ao limpar snapshots:", err)
+				} else if n > 0 {
+					log.Printf("🧹 %d snapshots removidos pela retenção.", n)
+				}
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
@@ -0,0 +1,79 @@
+package web
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Availability é o estado de estoque lido junto com o preço.
+type Availability string
+
+const (
+	AvailabilityUnknown    Availability = "unknown"
+	AvailabilityInStock    Availability = "in_stock"
+	AvailabilityOutOfStock Availability = "out_of_stock"
+	AvailabilityPreorder   Availability = "preorder"
+)
+
+// IsAvailable diz se o produto pode ser comprado agora (ou reservado).
+func (a Availability) IsAvailable() bool {
+	return a == AvailabilityInStock || a == AvailabilityPreorder
+}
+
+// parseSchemaAvailability traduz os valores de schema.org/ItemAvailability
+// (com ou sem o prefixo da URL) e as variações de og:availability.
+func parseSchemaAvailability(v string) Availability {
+	switch strings.ToLower(strings.ReplaceAll(schemaName(v), " ", "")) {
+	case "instock", "limitedavailability", "onlineonly", "instoreonly":
+		return AvailabilityInStock
+	case "outofstock", "soldout", "discontinued", "oos":
+		return AvailabilityOutOfStock
+	case "preorder", "presale", "backorder":
+		return AvailabilityPreorder
+	}
+	return AvailabilityUnknown
+}
+
+// metaAvailability lê microdata (itemprop=availability) e as meta tags de
+// produto do Open Graph.
+func metaAvailability(doc *goquery.Document) Availability {
+	sel := doc.Find("[itemprop='availability']").First()
+	if v, ok := sel.Attr("href"); ok {
+		if a := parseSchemaAvailability(v); a != AvailabilityUnknown {
+			return a
+		}
+	}
+	if v, ok := sel.Attr("content"); ok {
+		if a := parseSchemaAvailability(v); a != AvailabilityUnknown {
+			return a
+		}
+	}
+
+	for _, prop := range []string{"product:availability", "og:availability"} {
+		if v, ok := doc.Find("meta[property='" + prop + "']").Attr("content"); ok {
+			if a := parseSchemaAvailability(v); a != AvailabilityUnknown {
+				return a
+			}
+		}
+	}
+	return AvailabilityUnknown
+}
+
+// textAvailability procura marcadores de estoque em texto livre, como os que
+// as lojas exibem perto do botão de compra.
+func textAvailability(text string) Availability {
+	t := strings.ToLower(text)
+	switch {
+	case strings.Contains(t, "pré-venda"), strings.Contains(t, "pre-venda"), strings.Contains(t, "pré-encomenda"):
+		return AvailabilityPreorder
+	case strings.Contains(t, "indisponível"), strings.Contains(t, "esgotado"), strings.Contains(t, "sem estoque"),
+		strings.Contains(t, "não disponível"), strings.Contains(t, "avise-me"), strings.Contains(t, "currently unavailable"),
+		strings.Contains(t, "pausad"):
+		return AvailabilityOutOfStock
+	case strings.Contains(t, "em estoque"), strings.Contains(t, "estoque disponível"), strings.Contains(t, "disponível"),
+		strings.Contains(t, "in stock"):
+		return AvailabilityInStock
+	}
+	return AvailabilityUnknown
+}
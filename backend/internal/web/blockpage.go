@@ -0,0 +1,98 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrBlocked indica que a loja respondeu com uma página de captcha ou de
+// bloqueio anti-bot no lugar do produto.
+var ErrBlocked = errors.New("loja respondeu com página de bloqueio/captcha")
+
+// blockFingerprint reconhece a página de bloqueio de um fornecedor. Basta um
+// dos marcadores no HTML ou um dos trechos na URL final.
+type blockFingerprint struct {
+	name    string
+	markers []string
+	paths   []string
+}
+
+var blockFingerprints = []blockFingerprint{
+	{
+		name: "amazon-captcha",
+		markers: []string{
+			"/errors/validateCaptcha",
+			"Digite os caracteres que você vê abaixo",
+			"Type the characters you see in this image",
+			"api-services-support@amazon.com",
+		},
+	},
+	{
+		name:    "mercadolivre-verification",
+		markers: []string{"negative_traffic", "suspicious-traffic-frontend"},
+		paths:   []string{"/gz/account-verification", "/jms/lgz/"},
+	},
+	{
+		// Só marcadores da tela de desafio: o script de detecção
+		// (/cdn-cgi/challenge-platform/h/.../jsd/) é injetado em páginas normais.
+		name: "cloudflare",
+		markers: []string{
+			"cf-browser-verification",
+			"cf_chl_opt",
+			"<title>Just a moment...</title>",
+			"Attention Required! | Cloudflare",
+		},
+		paths: []string{"/cdn-cgi/challenge-platform"},
+	},
+	{
+		name:    "perimeterx",
+		markers: []string{"px-captcha", "_pxCaptcha", "captcha.px-cdn.net"},
+	},
+	{
+		name:    "datadome",
+		markers: []string{"captcha-delivery.com", "datadome.co/captcha"},
+	},
+	{
+		// _Incapsula_Resource também aparece em páginas normais protegidas.
+		name:    "imperva",
+		markers: []string{"Incapsula incident ID"},
+	},
+	{
+		name:    "akamai",
+		markers: []string{"errors.edgesuite.net"},
+	},
+	{
+		name:    "recaptcha-interstitial",
+		markers: []string{"<title>Robot Check</title>", "Are you a robot?", "Você é um robô?"},
+	},
+}
+
+// detectBlockPage devolve um ScrapeError do tipo blocked quando a página é
+// uma tela de captcha ou bloqueio conhecida.
+func detectBlockPage(body []byte, pageURL *url.URL) error {
+	path := ""
+	if pageURL != nil {
+		path = pageURL.Path
+	}
+
+	for _, fp := range blockFingerprints {
+		for _, p := range fp.paths {
+			if strings.Contains(path, p) {
+				return blockedError(fp.name)
+			}
+		}
+		for _, m := range fp.markers {
+			if bytes.Contains(body, []byte(m)) {
+				return blockedError(fp.name)
+			}
+		}
+	}
+	return nil
+}
+
+func blockedError(name string) error {
+	return &ScrapeError{Kind: ErrKindBlocked, Err: fmt.Errorf("%w (%s)", ErrBlocked, name)}
+}
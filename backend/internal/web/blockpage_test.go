@@ -0,0 +1,48 @@
+package web
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestDetectBlockPage(t *testing.T) {
+	product, _ := url.Parse("https://www.loja.com.br/produto/123")
+
+	cases := []struct {
+		name    string
+		html    string
+		blocked bool
+	}{
+		{
+			name: "página normal com script de detecção da cloudflare",
+			html: `<html><head><title>Fone Bluetooth</title>
+<script src="/cdn-cgi/challenge-platform/h/b/scripts/jsd/a1b2c3/main.js"></script></head>
+<body><h1>Fone Bluetooth</h1><span itemprop="price" content="199.90">R$ 199,90</span></body></html>`,
+		},
+		{
+			name: "página normal protegida pela imperva",
+			html: `<html><body><span itemprop="price">R$ 99,90</span>
+<script src="/_Incapsula_Resource?SWJIYLWA=719d34d31c8e3a6e6fffd425f7e032f3"></script></body></html>`,
+		},
+		{
+			name:    "desafio da cloudflare",
+			html:    `<html><head><title>Just a moment...</title></head><body><script>window._cf_chl_opt={cvId:'3'}</script></body></html>`,
+			blocked: true,
+		},
+		{
+			name:    "captcha da amazon",
+			html:    `<html><body><form action="/errors/validateCaptcha"><h4>Digite os caracteres que você vê abaixo</h4></form></body></html>`,
+			blocked: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := detectBlockPage([]byte(c.html), product)
+			if got := errors.Is(err, ErrBlocked); got != c.blocked {
+				t.Fatalf("bloqueada = %v (%v), queria %v", got, err, c.blocked)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Canonicalizer é implementado pelos extractors que sabem reduzir a URL de
+// um produto à sua forma mínima (ASIN, código MLB, etc.).
+type Canonicalizer interface {
+	Canonicalize(u *url.URL) *url.URL
+}
+
+// Encurtadores resolvidos antes da canonicalização.
+var shortLinkHosts = map[string]bool{
+	"amzn.to":                  true,
+	"a.co":                     true,
+	"amzn.com":                 true,
+	"mercadolivre.com":         true,
+	"mercadolibre.com":         true,
+	"bit.ly":                   true,
+	"tinyurl.com":              true,
+	"t.co":                     true,
+	"compre.vc":                true,
+	"shope.ee":                 true,
+	"s.shopee.com.br":          true,
+	"magazineluiza.onelink.me": true,
+}
+
+// Parâmetros de rastreamento que nunca mudam o produto exibido.
+var trackingParams = map[string]bool{
+	"ref": true, "ref_": true, "tag": true, "linkcode": true, "psc": true, "smid": true,
+	"th": true, "pf_rd_p": true, "pf_rd_r": true, "pd_rd_w": true, "pd_rd_r": true, "pd_rd_wg": true,
+	"content-id": true, "qid": true, "sr": true, "keywords": true, "crid": true, "sprefix": true,
+	"fbclid": true, "gclid": true, "gbraid": true, "wbraid": true, "msclkid": true, "srsltid": true,
+	"matt_tool": true, "matt_word": true, "forceinapp": true, "tracking_id": true,
+	"searchvariation": true, "position": true, "search_layout": true,
+	"c_id": true, "c_uid": true, "c_element_order": true, "c_campaign": true, "c_label": true,
+	"spm": true, "sp_atk": true, "xptdk": true, "partner_id": true, "seller_id": true,
+}
+
+// CanonicalURL resolve links encurtados e devolve a forma canônica da URL do
+// produto, usada para detectar o mesmo item adicionado duas vezes.
+func CanonicalURL(ctx context.Context, rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" {
+		u, err = url.Parse("https://" + strings.TrimSpace(rawURL))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if isShortLink(u) {
+		resolved, err := resolveRedirects(ctx, u)
+		if err != nil {
+			return "", err
+		}
+		u = resolved
+	}
+
+	u = stripTracking(u)
+	if c, ok := ExtractorFor(u.Hostname()).(Canonicalizer); ok {
+		u = c.Canonicalize(u)
+	}
+	return u.String(), nil
+}
+
+func isShortLink(u *url.URL) bool {
+	host := normalizeHost(u.Hostname())
+	if host == "mercadolivre.com" || host == "mercadolibre.com" {
+		return strings.HasPrefix(u.Path, "/sec/")
+	}
+	return shortLinkHosts[host]
+}
+
+// resolveRedirects segue os redirecionamentos com o cliente compartilhado e
+// devolve a URL final, sem baixar o corpo da página.
+func resolveRedirects(ctx context.Context, u *url.URL) (*url.URL, error) {
+	client, cfg := sharedClient()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", cfg.UserAgent)
+
+	if err := polite.wait(ctx, req.URL); err != nil {
+		return nil, err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, &ScrapeError{Kind: ErrKindNetwork, Err: err}
+	}
+	res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return nil, &StatusError{Code: res.StatusCode}
+	}
+	return res.Request.URL, nil
+}
+
+// stripTracking normaliza host e esquema, remove o fragmento (#position=...)
+// e os parâmetros de rastreamento, e ordena o que sobrar.
+func stripTracking(u *url.URL) *url.URL {
+	out := *u
+	out.Scheme = "https"
+	out.Host = strings.ToLower(u.Host)
+	out.Fragment = ""
+	out.RawFragment = ""
+	out.User = nil
+
+	q := u.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		lk := strings.ToLower(k)
+		if trackingParams[lk] || strings.HasPrefix(lk, "utm_") || strings.HasPrefix(lk, "pf_rd_") || strings.HasPrefix(lk, "pd_rd_") {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	clean := url.Values{}
+	for _, k := range keys {
+		clean[k] = q[k]
+	}
+	out.RawQuery = clean.Encode()
+
+	if len(out.Path) > 1 {
+		out.Path = strings.TrimSuffix(out.Path, "/")
+		out.RawPath = ""
+	}
+	return &out
+}
@@ -0,0 +1,162 @@
+package web
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"golang.org/x/net/html/charset"
+)
+
+// ClientConfig controla o cliente HTTP compartilhado do scraper.
+type ClientConfig struct {
+	Timeout             time.Duration
+	DialTimeout         time.Duration
+	TLSHandshakeTimeout time.Duration
+	IdleConnTimeout     time.Duration
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	UserAgent           string
+	// InsecureSkipVerify só deve ser usado para depurar lojas com
+	// certificados quebrados.
+	InsecureSkipVerify bool
+}
+
+const defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36"
+
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		Timeout:             15 * time.Second,
+		DialTimeout:         5 * time.Second,
+		TLSHandshakeTimeout: 5 * time.Second,
+		IdleConnTimeout:     90 * time.Second,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 4,
+		MaxConnsPerHost:     8,
+		UserAgent:           defaultUserAgent,
+	}
+}
+
+// ClientConfigFromEnv aplica as variáveis SCRAPER_* sobre os valores padrão.
+func ClientConfigFromEnv() ClientConfig {
+	cfg := DefaultClientConfig()
+	cfg.Timeout = envDuration("SCRAPER_TIMEOUT", cfg.Timeout)
+	cfg.DialTimeout = envDuration("SCRAPER_DIAL_TIMEOUT", cfg.DialTimeout)
+	cfg.TLSHandshakeTimeout = envDuration("SCRAPER_TLS_TIMEOUT", cfg.TLSHandshakeTimeout)
+	cfg.IdleConnTimeout = envDuration("SCRAPER_IDLE_TIMEOUT", cfg.IdleConnTimeout)
+	cfg.MaxIdleConns = envInt("SCRAPER_MAX_IDLE_CONNS", cfg.MaxIdleConns)
+	cfg.MaxIdleConnsPerHost = envInt("SCRAPER_MAX_IDLE_CONNS_PER_HOST", cfg.MaxIdleConnsPerHost)
+	cfg.MaxConnsPerHost = envInt("SCRAPER_MAX_CONNS_PER_HOST", cfg.MaxConnsPerHost)
+	if ua := os.Getenv("SCRAPER_USER_AGENT"); ua != "" {
+		cfg.UserAgent = ua
+	}
+	cfg.InsecureSkipVerify = os.Getenv("SCRAPER_INSECURE_TLS") == "true"
+	return cfg
+}
+
+var (
+	clientMu     sync.RWMutex
+	clientConfig = DefaultClientConfig()
+	httpClient   = newHTTPClient(clientConfig)
+)
+
+// ConfigureClient troca o cliente compartilhado. Deve ser chamado na
+// inicialização, antes do worker começar a fazer requisições.
+func ConfigureClient(cfg ClientConfig) {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+
+	if old, ok := httpClient.Transport.(*http.Transport); ok {
+		old.CloseIdleConnections()
+	}
+	clientConfig = cfg
+	httpClient = newHTTPClient(cfg)
+}
+
+func sharedClient() (*http.Client, ClientConfig) {
+	clientMu.RLock()
+	defer clientMu.RUnlock()
+	return httpClient, clientConfig
+}
+
+func newHTTPClient(cfg ClientConfig) *http.Client {
+	tr := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   cfg.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:   true,
+		TLSHandshakeTimeout: cfg.TLSHandshakeTimeout,
+		IdleConnTimeout:     cfg.IdleConnTimeout,
+		MaxIdleConns:        cfg.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     cfg.MaxConnsPerHost,
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: cfg.InsecureSkipVerify,
+			ClientSessionCache: tls.NewLRUClientSessionCache(256),
+		},
+		// A descompressão é feita em decodeBody para também aceitar brotli.
+		DisableCompression: true,
+	}
+	return &http.Client{Transport: tr, Timeout: cfg.Timeout}
+}
+
+// decodeBody desfaz o Content-Encoding e converte a página para UTF-8 de
+// acordo com o charset do cabeçalho ou das meta tags (ISO-8859-1, etc.).
+func decodeBody(res *http.Response) (io.Reader, func() error, error) {
+	var body io.Reader = res.Body
+	closeFn := func() error { return nil }
+
+	switch strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(res.Body)
+		if err != nil {
+			return nil, nil, err
+		}
+		body, closeFn = gz, gz.Close
+	case "deflate":
+		zr, err := zlib.NewReader(res.Body)
+		if err != nil {
+			return nil, nil, err
+		}
+		body, closeFn = zr, zr.Close
+	case "br":
+		body = brotli.NewReader(res.Body)
+	}
+
+	utf8, err := charset.NewReader(body, res.Header.Get("Content-Type"))
+	if err != nil {
+		closeFn()
+		return nil, nil, err
+	}
+	return utf8, closeFn, nil
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return def
+}
+
+func envInt(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return def
+}
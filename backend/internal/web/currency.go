@@ -0,0 +1,78 @@
+package web
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var currencySymbols = []struct {
+	symbol string
+	code   string
+}{
+	{"R$", "BRL"},
+	{"US$", "USD"},
+	{"USD", "USD"},
+	{"€", "EUR"},
+	{"£", "GBP"},
+	{"¥", "JPY"},
+}
+
+// currencyByTLD só lista domínios de país; .com, .net e afins não dizem
+// nada sobre a moeda (muitas lojas brasileiras usam .com).
+var currencyByTLD = map[string]string{
+	"br": "BRL",
+	"ar": "ARS",
+	"mx": "MXN",
+	"cl": "CLP",
+	"uk": "GBP",
+	"de": "EUR",
+	"fr": "EUR",
+	"es": "EUR",
+	"it": "EUR",
+	"pt": "EUR",
+	"jp": "JPY",
+	"us": "USD",
+}
+
+// detectCurrency descobre a moeda de uma página sem JSON-LD: primeiro pelas
+// meta tags, depois pelo símbolo ao lado do preço e, por fim, pelo domínio.
+func detectCurrency(doc *goquery.Document, pageURL *url.URL) string {
+	for _, sel := range []string{
+		"meta[itemprop='priceCurrency']",
+		"meta[property='product:price:currency']",
+		"meta[property='og:price:currency']",
+	} {
+		if v, ok := doc.Find(sel).Attr("content"); ok && strings.TrimSpace(v) != "" {
+			return strings.ToUpper(strings.TrimSpace(v))
+		}
+	}
+
+	text := doc.Find("[itemprop='price'], [class*='price'], [class*='Price']").First().Text()
+	if code := currencyFromText(text); code != "" {
+		return code
+	}
+
+	return currencyFromHost(pageURL.Hostname())
+}
+
+// currencyFromText reconhece símbolos não ambíguos; "$" sozinho é usado por
+// várias moedas e fica para a detecção pelo domínio.
+func currencyFromText(text string) string {
+	for _, s := range currencySymbols {
+		if strings.Contains(text, s.symbol) {
+			return s.code
+		}
+	}
+	return ""
+}
+
+func currencyFromHost(host string) string {
+	host = strings.ToLower(host)
+	i := strings.LastIndex(host, ".")
+	if i < 0 {
+		return ""
+	}
+	return currencyByTLD[host[i+1:]]
+}
@@ -0,0 +1,107 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ErrorKind classifica por que uma coleta falhou.
+type ErrorKind string
+
+const (
+	ErrKindNetwork    ErrorKind = "network"
+	ErrKindHTTPStatus ErrorKind = "http_status"
+	ErrKindBlocked    ErrorKind = "blocked"
+	ErrKindNotFound   ErrorKind = "not_found"
+	ErrKindParse      ErrorKind = "parse"
+	ErrKindZeroPrice  ErrorKind = "zero_price"
+	// ErrKindSuspended é quando nem tentamos: o circuito da loja está aberto.
+	ErrKindSuspended ErrorKind = "suspended"
+)
+
+// ScrapeError carrega a classificação de uma falha de coleta.
+type ScrapeError struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *ScrapeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ScrapeError) Unwrap() error {
+	return e.Err
+}
+
+// ErrZeroPrice indica uma página lida sem erro, mas sem preço, com o produto
+// aparentemente à venda.
+var ErrZeroPrice = &ScrapeError{Kind: ErrKindZeroPrice, Err: errors.New("nenhum preço encontrado na página")}
+
+// KindOf classifica qualquer erro devolvido por ScrapeProduct.
+func KindOf(err error) ErrorKind {
+	if err == nil {
+		return ""
+	}
+
+	var scrapeErr *ScrapeError
+	if errors.As(err, &scrapeErr) {
+		return scrapeErr.Kind
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.Code {
+		case http.StatusNotFound, http.StatusGone:
+			return ErrKindNotFound
+		case http.StatusForbidden:
+			return ErrKindBlocked
+		}
+		return ErrKindHTTPStatus
+	}
+
+	switch {
+	case errors.Is(err, ErrBlocked):
+		return ErrKindBlocked
+	case errors.Is(err, ErrCircuitOpen):
+		return ErrKindSuspended
+	case errors.Is(err, ErrDisallowedByRobots):
+		return ErrKindBlocked
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrKindNetwork
+	}
+	return ErrKindParse
+}
+
+// StatusCode devolve o status HTTP de uma falha, quando houver.
+func StatusCode(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code
+	}
+	return 0
+}
+
+// Problem aponta leituras que não servem como amostra de preço mesmo sem
+// erro de coleta.
+func (r ScrapeResult) Problem() error {
+	if r.Price == 0 && r.Availability != AvailabilityOutOfStock {
+		return ErrZeroPrice
+	}
+	return nil
+}
+
+// classifyReadError separa falhas de rede no meio do corpo (conexão caiu,
+// timeout) de HTML que não pôde ser lido.
+func classifyReadError(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, context.DeadlineExceeded) {
+		return &ScrapeError{Kind: ErrKindNetwork, Err: err}
+	}
+	return &ScrapeError{Kind: ErrKindParse, Err: err}
+}
@@ -0,0 +1,213 @@
+package web
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/publicsuffix"
+
+	"price-analyzer-backend/internal/data"
+	"price-analyzer-backend/internal/money"
+)
+
+// ScrapeResult é o que um extractor conseguiu ler de uma página de produto.
+type ScrapeResult struct {
+	Title    string
+	ImageURL string
+	Price    money.Amount
+	Currency string
+	SKU      string
+	GTIN     string
+
+	// Preço à vista (Pix/boleto), preço no cartão e plano de parcelamento,
+	// quando a loja exibe cada um separadamente.
+	CashPrice        money.Amount
+	CardPrice        money.Amount
+	Installments     int
+	InstallmentPrice money.Amount
+
+	// ListPrice é o preço "de" riscado que a loja exibe ao lado do preço de venda.
+	ListPrice money.Amount
+
+	Availability Availability
+
+	// SnapshotHash identifica o HTML bruto guardado no store de snapshots,
+	// quando ele está ativo.
+	SnapshotHash string
+
+	// Extractor e Strategy dizem de onde veio o preço: qual extractor foi
+	// consultado e por qual caminho (JSON-LD, seletores da loja...).
+	Extractor string
+	Strategy  string
+
+	// Confidence vai de 0 a 1; só é menor que 1 quando o preço veio da
+	// heurística.
+	Confidence float64
+}
+
+// NeedsConfirmation indica que o preço é um palpite da heurística e o
+// usuário deveria confirmá-lo.
+func (r ScrapeResult) NeedsConfirmation() bool {
+	return r.Strategy == StrategyHeuristic && r.Confidence < heuristicConfirmThreshold
+}
+
+// Estratégias de extração reportadas em ScrapeResult.Strategy.
+const (
+	StrategyJSONLD    = "json-ld"
+	StrategyPageState = "page-state"
+	StrategySelectors = "selectors"
+	StrategyHeuristic = "heuristic"
+	// StrategyPlatformAPI indica que os dados vieram da API da plataforma
+	// (VTEX, Shopify...) e não do HTML.
+	StrategyPlatformAPI = "platform-api"
+	StrategyNone        = "none"
+)
+
+// fill completa os campos vazios de r com os de other.
+func (r *ScrapeResult) fill(other ScrapeResult) {
+	if r.Title == "" {
+		r.Title = other.Title
+	}
+	if r.ImageURL == "" {
+		r.ImageURL = other.ImageURL
+	}
+	if r.Price == 0 {
+		r.Price = other.Price
+		r.Currency = other.Currency
+	}
+	if r.Currency == "" {
+		r.Currency = other.Currency
+	}
+	if r.SKU == "" {
+		r.SKU = other.SKU
+	}
+	if r.GTIN == "" {
+		r.GTIN = other.GTIN
+	}
+	if r.CashPrice == 0 {
+		r.CashPrice = other.CashPrice
+	}
+	if r.CardPrice == 0 {
+		r.CardPrice = other.CardPrice
+	}
+	if r.Installments == 0 {
+		r.Installments = other.Installments
+		r.InstallmentPrice = other.InstallmentPrice
+	}
+	if r.ListPrice == 0 {
+		r.ListPrice = other.ListPrice
+	}
+	if r.Availability == "" || r.Availability == AvailabilityUnknown {
+		r.Availability = other.Availability
+	}
+}
+
+// Extractor lê os dados de produto de uma página já baixada. Cada loja
+// registra o seu para os hosts que atende.
+type Extractor interface {
+	Name() string
+	Extract(doc *goquery.Document, pageURL *url.URL) ScrapeResult
+}
+
+var (
+	registry hostTable[Extractor]
+	fallback Extractor = genericExtractor{}
+)
+
+// RegisterExtractor associa um extractor a um ou mais hosts. Subdomínios
+// (www., produto., etc.) são resolvidos automaticamente pelo domínio pai, e
+// hosts com curingas ("*.myshopify.com") valem como padrão.
+func RegisterExtractor(e Extractor, hosts ...string) {
+	registry.set(e, hosts...)
+}
+
+// ExtractorFor devolve o extractor registrado para o host, ou o genérico
+// quando a loja não tem tratamento próprio.
+func ExtractorFor(host string) Extractor {
+	if e, ok := registry.get(host); ok {
+		return e
+	}
+	return fallback
+}
+
+// Sample converte o resultado em uma amostra para o histórico de preços.
+func (r ScrapeResult) Sample() data.PriceSample {
+	return data.PriceSample{
+		Price:            r.Price,
+		CashPrice:        r.CashPrice,
+		CardPrice:        r.CardPrice,
+		InstallmentCount: r.Installments,
+		InstallmentPrice: r.InstallmentPrice,
+		ListPrice:        r.ListPrice,
+		Currency:         r.Currency,
+		Availability:     string(r.Availability),
+		SnapshotHash:     r.SnapshotHash,
+	}
+}
+
+// extractProduct combina as fontes da página, da mais confiável para a
+// menos: JSON-LD, estado embutido de lojas SPA, extractor da loja e, para
+// lojas sem extractor próprio, a heurística. Cada fonte só completa o que
+// as anteriores deixaram vazio.
+func extractProduct(doc *goquery.Document, pageURL *url.URL) ScrapeResult {
+	e := ExtractorFor(pageURL.Hostname())
+
+	r, _ := jsonLDProduct(doc)
+	r.Extractor = e.Name()
+	r.Strategy = StrategyJSONLD
+	r.Confidence = 1
+	if r.Price == 0 {
+		r.Strategy = StrategyPageState
+	}
+	if paths, ok := pageStatePathsFor(pageURL.Hostname()); ok {
+		state, _ := pageStateProduct(doc, paths)
+		r.fill(state)
+	}
+	if r.Price == 0 {
+		r.Strategy = StrategySelectors
+	}
+	r.fill(e.Extract(doc, pageURL))
+	if r.Price == 0 && e == fallback {
+		r.Price, r.Confidence = heuristicPrice(doc, pageURL)
+		r.Strategy = StrategyHeuristic
+	}
+	if r.Price == 0 {
+		r.Strategy = StrategyNone
+		r.Confidence = 0
+	}
+	if r.Availability == "" {
+		r.Availability = AvailabilityUnknown
+	}
+	if r.Currency == "" {
+		r.Currency = detectCurrency(doc, pageURL)
+	}
+	return r
+}
+
+// StoreHost devolve o domínio registrável da loja de uma URL de produto
+// (produto.mercadolivre.com.br vira mercadolivre.com.br), usado para agrupar
+// requisições, limites de ritmo e o circuit breaker por loja.
+func StoreHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return storeDomain(u.Hostname())
+}
+
+// storeDomain reduz um host ao domínio registrável pela lista de sufixos
+// públicos. Subdomínios de plataformas como myshopify.com continuam
+// separados, porque cada um é uma loja.
+func storeDomain(host string) string {
+	host = normalizeHost(host)
+	if d, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return d
+	}
+	return host
+}
+
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	return strings.TrimPrefix(host, "www.")
+}
@@ -0,0 +1,70 @@
+package web
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"price-analyzer-backend/internal/money"
+)
+
+// genericExtractor usa apenas metadados padrão (Open Graph, Twitter Cards e
+// microdata) e serve de base para os extractors específicos.
+type genericExtractor struct{}
+
+func (genericExtractor) Name() string { return "generic" }
+
+func (genericExtractor) Extract(doc *goquery.Document, pageURL *url.URL) ScrapeResult {
+	return ScrapeResult{
+		Title:    metaTitle(doc),
+		ImageURL: metaImage(doc),
+		Price:    metaPrice(doc),
+
+		ListPrice: metaListPrice(doc),
+
+		Availability: metaAvailability(doc),
+	}
+}
+
+func metaTitle(doc *goquery.Document) string {
+	title, _ := doc.Find("meta[property='og:title']").Attr("content")
+	if title == "" {
+		title = doc.Find("title").Text()
+	}
+	return strings.TrimSpace(title)
+}
+
+func metaImage(doc *goquery.Document) string {
+	image, _ := doc.Find("meta[property='og:image']").Attr("content")
+	if image == "" {
+		image, _ = doc.Find("meta[name='twitter:image']").Attr("content")
+	}
+	return image
+}
+
+func metaPrice(doc *goquery.Document) money.Amount {
+	metaPrice, exists := doc.Find("meta[itemprop='price']").Attr("content")
+	if !exists {
+		return 0
+	}
+	p, err := money.Parse(metaPrice)
+	if err != nil {
+		return 0
+	}
+	return p
+}
+
+// metaListPrice lê o preço "de" das meta tags de produto ou, na falta delas,
+// do texto "de R$ X por R$ Y" do bloco de preço marcado com microdata.
+func metaListPrice(doc *goquery.Document) money.Amount {
+	for _, prop := range []string{"product:original_price:amount", "og:original_price:amount"} {
+		if v, ok := doc.Find("meta[property='" + prop + "']").Attr("content"); ok {
+			if p, err := money.Parse(v); err == nil {
+				return p
+			}
+		}
+	}
+	list, _ := listPriceFromText(doc.Find("[itemprop='offers']").Text())
+	return list
+}
@@ -0,0 +1,195 @@
+package web
+
+import (
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+
+	"price-analyzer-backend/internal/money"
+)
+
+// Abaixo disso o preço achado pela heurística precisa ser confirmado pelo
+// usuário antes de ser monitorado.
+const heuristicConfirmThreshold = 0.6
+
+var (
+	priceTextRe  = regexp.MustCompile(`(?:R\$|US\$|U\$|€|£|\$)\s*\d|\d\s*(?:€|R\$)`)
+	fontSizeRe   = regexp.MustCompile(`font-size\s*:\s*(\d+(?:\.\d+)?)\s*(px|rem|em)`)
+	addToCartRe  = regexp.MustCompile(`(?i)comprar|adicionar ao carrinho|add to cart|add-to-cart|buy now|añadir al carrito`)
+	priceHintRe  = regexp.MustCompile(`(?i)price|pre[cç]o|valor|amount`)
+	notPriceRe   = regexp.MustCompile(`(?i)old|list|from|was|strike|installment|parcel|frete|shipping|economi|saving|discount|desconto`)
+	notPriceText = regexp.MustCompile(`(?i)\d+\s*x\s*(?:de)?\s*(?:R\$|\$)|/\s*m[eê]s|parcela|frete|economize|\boff\b|\bde:`)
+)
+
+// priceCandidate é um trecho da página que parece um preço.
+type priceCandidate struct {
+	amount money.Amount
+	score  float64
+}
+
+// heuristicPrice procura o preço principal numa página sem dados
+// estruturados nem extractor próprio. Cada trecho com cara de preço ganha
+// pontos pela classe, pelo tamanho da fonte e pela proximidade do título e
+// do botão de compra; o melhor vence. A confiança (0 a 1) cai quando há
+// pouca pontuação ou outro valor quase empatado. Formatos ambíguos seguem
+// a moeda detectada na página; o símbolo de cada trecho tem a palavra final.
+func heuristicPrice(doc *goquery.Document, pageURL *url.URL) (money.Amount, float64) {
+	loc := money.LocaleForCurrency(detectCurrency(doc, pageURL))
+
+	var title *html.Node
+	if h1 := doc.Find("h1").First(); h1.Length() > 0 {
+		title = h1.Get(0)
+	}
+	cart := addToCartNode(doc)
+
+	// Um candidato por valor, na ordem em que aparece na página, para que
+	// empates sejam decididos sempre do mesmo jeito.
+	var candidates []priceCandidate
+	seen := map[money.Amount]int{}
+	doc.Find("body *").Each(func(_ int, s *goquery.Selection) {
+		if s.Is("script, style, noscript, select, option, button, a") {
+			return
+		}
+		text := strings.Join(strings.Fields(s.Text()), " ")
+		if len(text) == 0 || len(text) > 48 || !priceTextRe.MatchString(text) {
+			return
+		}
+		// Prefere o elemento mais interno que ainda contém o preço inteiro.
+		inner := false
+		s.Children().EachWithBreak(func(_ int, c *goquery.Selection) bool {
+			inner = priceTextRe.MatchString(c.Text())
+			return !inner
+		})
+		if inner {
+			return
+		}
+
+		p, err := money.ParseText(text, loc)
+		if err != nil || p.Amount <= 0 {
+			return
+		}
+
+		c := scoreCandidate(s, text, title, cart)
+		c.amount = p.Amount
+		i, ok := seen[c.amount]
+		if !ok {
+			seen[c.amount] = len(candidates)
+			candidates = append(candidates, c)
+			return
+		}
+		if c.score > candidates[i].score {
+			candidates[i].score = c.score
+		}
+	})
+
+	// Em caso de empate, vence o valor que aparece primeiro.
+	var top, runnerUp priceCandidate
+	for _, c := range candidates {
+		switch {
+		case c.score > top.score:
+			runnerUp, top = top, c
+		case c.score > runnerUp.score:
+			runnerUp = c
+		}
+	}
+	if top.score <= 0 {
+		return 0, 0
+	}
+
+	confidence := math.Min(1, top.score/8)
+	if runnerUp.score >= top.score*0.85 {
+		confidence *= 0.6
+	}
+	return top.amount, confidence
+}
+
+func scoreCandidate(s *goquery.Selection, text string, title, cart *html.Node) priceCandidate {
+	var c priceCandidate
+
+	// Classe/id do elemento e dos dois ancestrais mais próximos.
+	for i, sel := 0, s; i < 3 && sel.Length() > 0; i, sel = i+1, sel.Parent() {
+		attrs := sel.AttrOr("class", "") + " " + sel.AttrOr("id", "") + " " +
+			sel.AttrOr("itemprop", "") + " " + sel.AttrOr("data-testid", "")
+		if notPriceRe.MatchString(attrs) {
+			c.score -= 3
+		} else if priceHintRe.MatchString(attrs) {
+			c.score += 3
+			break
+		}
+	}
+
+	if s.Closest("del, s, strike").Length() > 0 {
+		c.score -= 4
+	}
+	if notPriceText.MatchString(text) {
+		c.score -= 2
+	}
+
+	c.score += fontScore(s)
+	if s.Is("strong, b, h2, h3, h4") || s.Parent().Is("strong, b, h2, h3, h4") {
+		c.score++
+	}
+
+	node := s.Get(0)
+	if title != nil {
+		c.score += 3 / (1 + float64(treeDistance(node, title))/4)
+	}
+	if cart != nil {
+		c.score += 2 / (1 + float64(treeDistance(node, cart))/4)
+	}
+	return c
+}
+
+// fontScore premia preços escritos com fonte grande via estilo inline.
+func fontScore(s *goquery.Selection) float64 {
+	for i, sel := 0, s; i < 3 && sel.Length() > 0; i, sel = i+1, sel.Parent() {
+		m := fontSizeRe.FindStringSubmatch(sel.AttrOr("style", ""))
+		if m == nil {
+			continue
+		}
+		size, _ := strconv.ParseFloat(m[1], 64)
+		if m[2] != "px" {
+			size *= 16
+		}
+		switch {
+		case size >= 24:
+			return 2
+		case size >= 18:
+			return 1
+		}
+		return 0
+	}
+	return 0
+}
+
+func addToCartNode(doc *goquery.Document) *html.Node {
+	var found *html.Node
+	doc.Find("button, a, input[type='submit'], [class*='add-to-cart'], [id*='add-to-cart']").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		label := s.Text() + " " + s.AttrOr("value", "") + " " + s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+		if addToCartRe.MatchString(label) {
+			found = s.Get(0)
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// treeDistance conta as arestas entre dois nós passando pelo ancestral comum.
+func treeDistance(a, b *html.Node) int {
+	depth := map[*html.Node]int{}
+	for n, d := a, 0; n != nil; n, d = n.Parent, d+1 {
+		depth[n] = d
+	}
+	for n, d := b, 0; n != nil; n, d = n.Parent, d+1 {
+		if da, ok := depth[n]; ok {
+			return da + d
+		}
+	}
+	return math.MaxInt32
+}
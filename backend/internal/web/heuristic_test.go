@@ -0,0 +1,78 @@
+package web
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"price-analyzer-backend/internal/money"
+)
+
+func TestHeuristicPriceLocale(t *testing.T) {
+	cases := []struct {
+		name string
+		url  string
+		html string
+		want money.Amount
+	}{
+		{
+			name: "real em domínio .com",
+			url:  "https://www.magalu.com/produto/123",
+			html: `<h1>Notebook</h1><div class="product-price" style="font-size: 32px">R$ 1.299</div><button>Comprar</button>`,
+			want: 129900,
+		},
+		{
+			name: "real em domínio .com.br",
+			url:  "https://www.loja.com.br/p/notebook",
+			html: `<h1>Notebook</h1><span class="price">R$ 1.299</span><button>Adicionar ao carrinho</button>`,
+			want: 129900,
+		},
+		{
+			name: "dólar pela meta tag da página",
+			url:  "https://shop.example.com/notebook",
+			html: `<meta property="product:price:currency" content="USD"><h1>Laptop</h1><span class="price">$1,299</span><button>Add to cart</button>`,
+			want: 129900,
+		},
+		{
+			name: "símbolo vence o domínio",
+			url:  "https://www.loja.com.br/p/notebook",
+			html: `<h1>Laptop</h1><span class="price">US$ 1,299.50</span><button>Comprar</button>`,
+			want: 129950,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			u, _ := url.Parse(c.url)
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader("<html><body>" + c.html + "</body></html>"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := heuristicPrice(doc, u)
+			if got != c.want {
+				t.Errorf("heuristicPrice = %v, queria %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestHeuristicPriceTieIsStable(t *testing.T) {
+	u, _ := url.Parse("https://www.loja.com.br/p/kit")
+	page := `<html><body><h1>Kit</h1><span class="price">R$ 10,00</span><span class="price">R$ 20,00</span><button>Comprar</button></body></html>`
+
+	for i := 0; i < 20; i++ {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, confidence := heuristicPrice(doc, u)
+		if got != 1000 {
+			t.Fatalf("heuristicPrice = %v na tentativa %d, queria 10.00", got, i)
+		}
+		if confidence >= heuristicConfirmThreshold {
+			t.Fatalf("confiança %.2f com dois preços empatados, queria abaixo de %.2f", confidence, heuristicConfirmThreshold)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package web
+
+import (
+	"path"
+	"strings"
+	"sync"
+)
+
+// hostTable associa valores a hosts. A busca tenta o host exato, depois os
+// domínios pai (www., produto., etc.) e por fim os padrões glob registrados
+// ("*.myshopify.com", "loja-*.com.br").
+type hostTable[T any] struct {
+	mu       sync.RWMutex
+	exact    map[string]T
+	patterns []hostPattern[T]
+}
+
+type hostPattern[T any] struct {
+	pattern string
+	value   T
+}
+
+func (t *hostTable[T]) set(v T, hosts ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.exact == nil {
+		t.exact = map[string]T{}
+	}
+	for _, h := range hosts {
+		h = normalizeHost(h)
+		if !strings.ContainsAny(h, "*?[") {
+			t.exact[h] = v
+			continue
+		}
+		t.patterns = append(t.patterns, hostPattern[T]{pattern: h, value: v})
+	}
+}
+
+func (t *hostTable[T]) remove(hosts ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, h := range hosts {
+		h = normalizeHost(h)
+		delete(t.exact, h)
+		kept := t.patterns[:0]
+		for _, p := range t.patterns {
+			if p.pattern != h {
+				kept = append(kept, p)
+			}
+		}
+		t.patterns = kept
+	}
+}
+
+func (t *hostTable[T]) get(host string) (T, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	h := normalizeHost(host)
+	for d := h; d != ""; {
+		if v, ok := t.exact[d]; ok {
+			return v, true
+		}
+		i := strings.Index(d, ".")
+		if i < 0 {
+			break
+		}
+		d = d[i+1:]
+	}
+	for _, p := range t.patterns {
+		if ok, _ := path.Match(p.pattern, h); ok {
+			return p.value, true
+		}
+	}
+	var zero T
+	return zero, false
+}
@@ -0,0 +1,247 @@
+package web
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"price-analyzer-backend/internal/money"
+)
+
+// jsonLDProduct procura um schema.org Product nos blocos
+// <script type="application/ld+json"> da página. Blocos podem trazer um
+// objeto, uma lista ou um @graph; ofertas podem ser Offer, AggregateOffer ou
+// listas aninhadas delas.
+func jsonLDProduct(doc *goquery.Document) (ScrapeResult, bool) {
+	var found ScrapeResult
+	ok := false
+
+	doc.Find("script[type='application/ld+json']").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		var raw interface{}
+		dec := json.NewDecoder(strings.NewReader(strings.TrimSpace(s.Text())))
+		dec.UseNumber()
+		if err := dec.Decode(&raw); err != nil {
+			return true
+		}
+		for _, node := range flattenJSONLD(raw) {
+			if !hasType(node, "Product") {
+				continue
+			}
+			r := productFromJSONLD(node)
+			if !ok || (found.Price == 0 && r.Price > 0) {
+				found, ok = r, true
+			}
+			if found.Price > 0 {
+				return false
+			}
+		}
+		return true
+	})
+
+	return found, ok
+}
+
+func flattenJSONLD(v interface{}) []map[string]interface{} {
+	var out []map[string]interface{}
+	switch t := v.(type) {
+	case []interface{}:
+		for _, item := range t {
+			out = append(out, flattenJSONLD(item)...)
+		}
+	case map[string]interface{}:
+		out = append(out, t)
+		if g, ok := t["@graph"]; ok {
+			out = append(out, flattenJSONLD(g)...)
+		}
+	}
+	return out
+}
+
+func hasType(node map[string]interface{}, want string) bool {
+	switch t := node["@type"].(type) {
+	case string:
+		return schemaName(t) == want
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok && schemaName(s) == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// schemaName remove o prefixo "http://schema.org/" de tipos e enums.
+func schemaName(s string) string {
+	if i := strings.LastIndex(s, "/"); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(s)
+}
+
+func productFromJSONLD(node map[string]interface{}) ScrapeResult {
+	r := ScrapeResult{
+		Title:    strings.TrimSpace(jsonString(node["name"])),
+		ImageURL: jsonImage(node["image"]),
+		SKU:      jsonString(node["sku"]),
+		GTIN:     firstNonEmpty(node, "gtin13", "gtin", "gtin14", "gtin12", "gtin8"),
+	}
+
+	for _, offer := range flattenOffers(node["offers"]) {
+		if a := parseSchemaAvailability(jsonString(offer["availability"])); availabilityRank(a) > availabilityRank(r.Availability) {
+			r.Availability = a
+		}
+
+		price := jsonNumber(offer["price"])
+		if price == 0 {
+			price = jsonNumber(offer["lowPrice"])
+		}
+		if price == 0 {
+			price = jsonNumber(offer["highPrice"])
+		}
+		if price == 0 {
+			price = jsonSalePrice(offer["priceSpecification"])
+		}
+		if price <= 0 {
+			continue
+		}
+		if lp := jsonListPrice(offer["priceSpecification"]); lp > price && r.ListPrice == 0 {
+			r.ListPrice = lp
+		}
+		if r.Price == 0 || price < r.Price {
+			r.Price = price
+			r.Currency = strings.ToUpper(jsonString(offer["priceCurrency"]))
+		}
+		if r.SKU == "" {
+			r.SKU = jsonString(offer["sku"])
+		}
+		if r.GTIN == "" {
+			r.GTIN = firstNonEmpty(offer, "gtin13", "gtin", "gtin14", "gtin12", "gtin8")
+		}
+	}
+
+	return r
+}
+
+// flattenOffers devolve as ofertas folha, descendo em AggregateOffer.offers.
+func flattenOffers(v interface{}) []map[string]interface{} {
+	var out []map[string]interface{}
+	switch t := v.(type) {
+	case []interface{}:
+		for _, item := range t {
+			out = append(out, flattenOffers(item)...)
+		}
+	case map[string]interface{}:
+		if nested, ok := t["offers"]; ok && hasType(t, "AggregateOffer") {
+			if inner := flattenOffers(nested); len(inner) > 0 {
+				for _, o := range inner {
+					if _, ok := o["priceCurrency"]; !ok {
+						o["priceCurrency"] = t["priceCurrency"]
+					}
+				}
+				return inner
+			}
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func firstNonEmpty(node map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if s := jsonString(node[k]); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+func jsonString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	case []interface{}:
+		if len(t) > 0 {
+			return jsonString(t[0])
+		}
+	case map[string]interface{}:
+		if s := jsonString(t["@id"]); s != "" {
+			return s
+		}
+		return jsonString(t["name"])
+	}
+	return ""
+}
+
+func jsonNumber(v interface{}) money.Amount {
+	switch t := v.(type) {
+	case json.Number:
+		a, _ := money.Parse(t.String())
+		return a
+	case string:
+		if a, err := money.Parse(t); err == nil {
+			return a
+		}
+		return parsePrice(t, money.LocaleUnknown)
+	}
+	return 0
+}
+
+func jsonImage(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, item := range t {
+			if s := jsonImage(item); s != "" {
+				return s
+			}
+		}
+	case map[string]interface{}:
+		if s := jsonString(t["url"]); s != "" {
+			return s
+		}
+		return jsonString(t["contentUrl"])
+	}
+	return ""
+}
+
+// availabilityRank ordena os estados para escolher o melhor entre várias
+// ofertas: basta uma em estoque para o produto estar disponível.
+func availabilityRank(a Availability) int {
+	switch a {
+	case AvailabilityInStock:
+		return 3
+	case AvailabilityPreorder:
+		return 2
+	case AvailabilityOutOfStock:
+		return 1
+	}
+	return 0
+}
+
+// jsonSalePrice e jsonListPrice leem priceSpecification, que pode ser um
+// objeto ou uma lista de UnitPriceSpecification com priceType "ListPrice"
+// (preço "de") ou "SalePrice".
+func jsonSalePrice(v interface{}) money.Amount {
+	for _, spec := range flattenOffers(v) {
+		if schemaName(jsonString(spec["priceType"])) != "ListPrice" {
+			if p := jsonNumber(spec["price"]); p > 0 {
+				return p
+			}
+		}
+	}
+	return 0
+}
+
+func jsonListPrice(v interface{}) money.Amount {
+	for _, spec := range flattenOffers(v) {
+		if schemaName(jsonString(spec["priceType"])) == "ListPrice" {
+			return jsonNumber(spec["price"])
+		}
+	}
+	return 0
+}
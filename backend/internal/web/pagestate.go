@@ -0,0 +1,385 @@
+package web
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"price-analyzer-backend/internal/money"
+)
+
+// PageStatePaths diz onde procurar cada campo no estado embutido na página
+// (__NEXT_DATA__, window.__PRELOADED_STATE__, cache do Apollo...). Cada campo
+// aceita vários caminhos, tentados em ordem.
+//
+// Um caminho é uma sequência de chaves separadas por ponto. "*" casa com
+// qualquer chave ou item de lista, "**" com qualquer profundidade, e chaves
+// aceitam curingas no estilo glob ("Product:*").
+type PageStatePaths struct {
+	Title        []string `json:"title"`
+	Image        []string `json:"image"`
+	Price        []string `json:"price"`
+	ListPrice    []string `json:"list_price"`
+	Currency     []string `json:"currency"`
+	Availability []string `json:"availability"`
+
+	// Cents indica que a loja guarda os preços em centavos inteiros.
+	// MinorUnits generaliza para outras escalas (a Shopee usa 5 casas).
+	Cents      bool `json:"cents"`
+	MinorUnits int  `json:"minor_units"`
+}
+
+// minorUnits devolve quantas casas decimais os preços inteiros embutem; zero
+// quando eles já vêm em reais.
+func (p PageStatePaths) minorUnits() int {
+	switch {
+	case p.MinorUnits > 0:
+		return p.MinorUnits
+	case p.Cents:
+		return 2
+	}
+	return 0
+}
+
+// Caminhos usados quando a loja não tem extractor nem caminhos próprios.
+// Cobrem os formatos mais comuns de lojas em Next.js, Redux e Apollo. Todos
+// são ancorados na raiz do estado: curingas de profundidade livre acabariam
+// achando produtos de acessórios e recomendações.
+var defaultPageStatePaths = PageStatePaths{
+	Title: []string{
+		"props.pageProps.product.name",
+		"props.pageProps.product.title",
+		"props.pageProps.*.product.name",
+		"props.pageProps.*.product.title",
+		"Product:*.name",
+		"product.name",
+		"product.title",
+		"*.product.name",
+		"*.product.title",
+	},
+	Image: []string{
+		"props.pageProps.product.image",
+		"props.pageProps.product.images",
+		"props.pageProps.*.product.image",
+		"props.pageProps.*.product.images",
+		"Product:*.image",
+		"product.image",
+		"product.images",
+		"product.imageUrl",
+		"*.product.image",
+		"*.product.images",
+		"*.product.imageUrl",
+	},
+	Price: []string{
+		"props.pageProps.product.price",
+		"props.pageProps.product.offers.price",
+		"props.pageProps.*.product.price",
+		"props.pageProps.*.product.offers.price",
+		"Product:*.price",
+		"product.price",
+		"product.salePrice",
+		"*.product.price",
+		"*.product.salePrice",
+		"*.product.offers.price",
+	},
+	ListPrice: []string{
+		"props.pageProps.product.listPrice",
+		"props.pageProps.*.product.listPrice",
+		"props.pageProps.*.product.originalPrice",
+		"props.pageProps.*.product.oldPrice",
+		"Product:*.listPrice",
+		"product.listPrice",
+		"product.originalPrice",
+		"product.oldPrice",
+		"*.product.listPrice",
+		"*.product.originalPrice",
+		"*.product.oldPrice",
+	},
+	Currency: []string{
+		"props.pageProps.product.currency",
+		"props.pageProps.*.product.currency",
+		"product.currency",
+		"product.priceCurrency",
+		"*.product.currency",
+		"*.product.priceCurrency",
+		"*.product.price.currency",
+	},
+	Availability: []string{
+		"props.pageProps.product.availability",
+		"props.pageProps.*.product.availability",
+		"props.pageProps.*.product.inStock",
+		"props.pageProps.*.product.available",
+		"Product:*.availability",
+		"product.availability",
+		"product.inStock",
+		"product.available",
+		"*.product.availability",
+		"*.product.inStock",
+		"*.product.available",
+	},
+}
+
+var pageStateRules hostTable[PageStatePaths]
+
+// RegisterPageStatePaths define os caminhos de estado usados para os hosts.
+func RegisterPageStatePaths(p PageStatePaths, hosts ...string) {
+	pageStateRules.set(p, hosts...)
+}
+
+// pageStatePathsFor devolve os caminhos registrados para o host ou, para
+// lojas sem extractor próprio, os padrões. Lojas com extractor e sem
+// caminhos ficam só com os seletores, que são mais confiáveis.
+func pageStatePathsFor(host string) (PageStatePaths, bool) {
+	if p, ok := pageStateRules.get(host); ok {
+		return p, true
+	}
+	if ExtractorFor(host) == fallback {
+		return defaultPageStatePaths, true
+	}
+	return PageStatePaths{}, false
+}
+
+// stateAssignRe acha atribuições como "window.__PRELOADED_STATE__ = ".
+var stateAssignRe = regexp.MustCompile(`(?:window\.)?(__[A-Z][A-Z_]*__)\s*=\s*`)
+
+// pageStates devolve os blobs de estado embutidos na página, já decodificados.
+func pageStates(doc *goquery.Document) []interface{} {
+	var states []interface{}
+
+	doc.Find("script#__NEXT_DATA__, script[type='application/json'][id]").Each(func(_ int, s *goquery.Selection) {
+		if v, ok := decodeState(s.Text()); ok {
+			states = append(states, v)
+		}
+	})
+
+	doc.Find("script:not([src])").Each(func(_ int, s *goquery.Selection) {
+		text := s.Text()
+		for _, loc := range stateAssignRe.FindAllStringIndex(text, -1) {
+			if raw := stateLiteral(text[loc[1]:]); raw != "" {
+				if v, ok := decodeState(raw); ok {
+					states = append(states, v)
+				}
+			}
+		}
+	})
+	return states
+}
+
+func decodeState(raw string) (interface{}, bool) {
+	dec := json.NewDecoder(strings.NewReader(strings.TrimSpace(raw)))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// stateLiteral recorta o valor atribuído: um objeto literal ou uma chamada
+// JSON.parse("...").
+func stateLiteral(js string) string {
+	js = strings.TrimSpace(js)
+	if rest, ok := strings.CutPrefix(js, "JSON.parse("); ok {
+		rest = strings.TrimSpace(rest)
+		if !strings.HasPrefix(rest, `"`) {
+			return ""
+		}
+		end := balancedEnd(rest)
+		if end < 0 {
+			return ""
+		}
+		var s string
+		if err := json.Unmarshal([]byte(rest[:end]), &s); err != nil {
+			return ""
+		}
+		return s
+	}
+	if !strings.HasPrefix(js, "{") && !strings.HasPrefix(js, "[") {
+		return ""
+	}
+	if end := balancedEnd(js); end > 0 {
+		return js[:end]
+	}
+	return ""
+}
+
+// balancedEnd devolve o fim do primeiro valor (objeto, lista ou string) de
+// s, respeitando strings e escapes.
+func balancedEnd(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			switch c {
+			case '\\':
+				i++
+			case quote:
+				quote = 0
+				if depth == 0 {
+					return i + 1
+				}
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// pageStateProduct lê o produto do estado embutido na página.
+func pageStateProduct(doc *goquery.Document, paths PageStatePaths) (ScrapeResult, bool) {
+	var r ScrapeResult
+	for _, state := range pageStates(doc) {
+		r.fill(productFromState(state, paths))
+	}
+	return r, r.Price > 0
+}
+
+func productFromState(state interface{}, p PageStatePaths) ScrapeResult {
+	minor := p.minorUnits()
+	r := ScrapeResult{
+		Title:     jsonString(firstMatch(state, p.Title, isNonEmptyString)),
+		ImageURL:  jsonImage(firstMatch(state, p.Image, nil)),
+		Price:     stateAmount(firstMatch(state, p.Price, isAmount(minor)), minor),
+		ListPrice: stateAmount(firstMatch(state, p.ListPrice, isAmount(minor)), minor),
+		Currency:  strings.ToUpper(jsonString(firstMatch(state, p.Currency, isNonEmptyString))),
+	}
+
+	switch v := firstMatch(state, p.Availability, nil).(type) {
+	case bool:
+		r.Availability = AvailabilityOutOfStock
+		if v {
+			r.Availability = AvailabilityInStock
+		}
+	case string:
+		r.Availability = parseSchemaAvailability(v)
+	case json.Number:
+		// Quantidade em estoque.
+		r.Availability = AvailabilityOutOfStock
+		if n, err := v.Int64(); err == nil && n > 0 {
+			r.Availability = AvailabilityInStock
+		}
+	}
+	return r
+}
+
+func isNonEmptyString(v interface{}) bool { return jsonString(v) != "" }
+
+func isAmount(minor int) func(interface{}) bool {
+	return func(v interface{}) bool { return stateAmount(v, minor) > 0 }
+}
+
+// stateAmount lê um preço que pode vir como número, texto ou objeto
+// ({"value": 10}, {"amount": 10, "currency": "BRL"}).
+func stateAmount(v interface{}, minor int) money.Amount {
+	if m, ok := v.(map[string]interface{}); ok {
+		for _, k := range []string{"value", "amount", "price", "current", "sale", "best"} {
+			if a := stateAmount(m[k], minor); a > 0 {
+				return a
+			}
+		}
+		return 0
+	}
+	if n, ok := v.(json.Number); ok && minor > 0 {
+		c, err := strconv.ParseInt(n.String(), 10, 64)
+		if err != nil {
+			return 0
+		}
+		return fromMinorUnits(c, minor)
+	}
+	return jsonNumber(v)
+}
+
+// firstMatch devolve o primeiro valor encontrado pelos caminhos, em ordem,
+// que satisfaça ok (quando informado).
+func firstMatch(root interface{}, paths []string, ok func(interface{}) bool) interface{} {
+	for _, p := range paths {
+		for _, v := range jsonPath(root, strings.Split(p, ".")) {
+			if v != nil && (ok == nil || ok(v)) {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// jsonPath resolve um caminho em JSON decodificado. As chaves de mapas são
+// visitadas em ordem alfabética para o resultado ser estável.
+func jsonPath(v interface{}, segs []string) []interface{} {
+	if len(segs) == 0 {
+		return []interface{}{v}
+	}
+	seg, rest := segs[0], segs[1:]
+
+	if seg == "**" {
+		out := jsonPath(v, rest)
+		for _, child := range jsonChildren(v) {
+			out = append(out, jsonPath(child, segs)...)
+		}
+		return out
+	}
+
+	var out []interface{}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if child, ok := t[seg]; ok {
+			return jsonPath(child, rest)
+		}
+		if !strings.ContainsAny(seg, "*?[") {
+			return nil
+		}
+		for _, k := range sortedKeys(t) {
+			if m, _ := path.Match(seg, k); m {
+				out = append(out, jsonPath(t[k], rest)...)
+			}
+		}
+	case []interface{}:
+		if seg == "*" {
+			for _, item := range t {
+				out = append(out, jsonPath(item, rest)...)
+			}
+		} else if i, err := strconv.Atoi(seg); err == nil && i >= 0 && i < len(t) {
+			out = jsonPath(t[i], rest)
+		}
+	}
+	return out
+}
+
+func jsonChildren(v interface{}) []interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make([]interface{}, 0, len(t))
+		for _, k := range sortedKeys(t) {
+			out = append(out, t[k])
+		}
+		return out
+	case []interface{}:
+		return t
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,44 @@
+package web
+
+import "testing"
+
+// Produtos de acessórios e recomendações no estado embutido não podem ser
+// confundidos com o produto da página.
+func TestPageStateIgnoresRelatedProducts(t *testing.T) {
+	runStoreFixtures(t, []storeFixture{
+		{
+			file: "kabum_accessories.html",
+			url:  "https://www.kabum.com.br/produto/475647/placa-de-video-rtx-4060-ventus-2x-black-msi",
+			want: ScrapeResult{
+				Title:            "Placa de Vídeo RTX 4060 Ventus 2X Black MSI NVIDIA GeForce, 8GB GDDR6",
+				ImageURL:         "https://images.kabum.com.br/produtos/fotos/sync_mirakl/475647/Placa-De-V-deo-RTX-4060-Ventus-2X-Black-MSI_1688139836_gg.jpg",
+				Price:            189999,
+				Currency:         "BRL",
+				CashPrice:        189999,
+				CardPrice:        223528,
+				Installments:     10,
+				InstallmentPrice: 22353,
+				ListPrice:        249999,
+				Availability:     AvailabilityInStock,
+				Extractor:        "kabum",
+				Strategy:         StrategySelectors,
+				Confidence:       1,
+			},
+		},
+		{
+			file: "kabum_accessories.html",
+			url:  "https://www.lojaexemplo.com.br/produto/475647",
+			want: ScrapeResult{
+				Title:        "Placa de Vídeo RTX 4060 Ventus 2X Black MSI NVIDIA GeForce, 8GB GDDR6",
+				ImageURL:     "https://images.kabum.com.br/produtos/fotos/sync_mirakl/475647/Placa-De-V-deo-RTX-4060-Ventus-2X-Black-MSI_1688139836_gg.jpg",
+				Price:        189999,
+				Currency:     "BRL",
+				ListPrice:    249999,
+				Availability: AvailabilityInStock,
+				Extractor:    "generic",
+				Strategy:     StrategyPageState,
+				Confidence:   1,
+			},
+		},
+	})
+}
@@ -0,0 +1,74 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Platform lê produtos pela API pública de uma plataforma de e-commerce
+// (VTEX, Shopify...), mais exata e barata que o HTML. A plataforma é
+// detectada na primeira página baixada da loja; daí em diante as coletas
+// vão direto à API.
+type Platform interface {
+	Name() string
+	Detect(doc *goquery.Document) bool
+	Fetch(ctx context.Context, productURL *url.URL) (ScrapeResult, error)
+}
+
+var (
+	platforms      []Platform
+	knownPlatforms hostTable[Platform]
+)
+
+// RegisterPlatform adiciona uma plataforma à detecção automática.
+func RegisterPlatform(p Platform) {
+	platforms = append(platforms, p)
+}
+
+func detectPlatform(doc *goquery.Document) Platform {
+	for _, p := range platforms {
+		if p.Detect(doc) {
+			return p
+		}
+	}
+	return nil
+}
+
+// fetchFromPlatform consulta a API da plataforma com as mesmas regras de
+// novas tentativas e circuito da loja usadas para o HTML.
+func fetchFromPlatform(ctx context.Context, p Platform, productURL string) (ScrapeResult, error) {
+	u, err := url.Parse(productURL)
+	if err != nil {
+		return ScrapeResult{}, err
+	}
+
+	var r ScrapeResult
+	err = withRetries(ctx, StoreHost(productURL), func() error {
+		var err error
+		r, err = p.Fetch(ctx, u)
+		return err
+	})
+	if err != nil {
+		return ScrapeResult{}, err
+	}
+
+	r.Extractor = p.Name()
+	r.Strategy = StrategyPlatformAPI
+	r.Confidence = 1
+	if r.Availability == "" {
+		r.Availability = AvailabilityUnknown
+	}
+	if r.Currency == "" {
+		r.Currency = currencyFromHost(u.Hostname())
+	}
+	return r, nil
+}
+
+// platformFallback diz se vale tentar o HTML depois de a API falhar: só não
+// vale quando a própria loja está suspensa ou bloqueando.
+func platformFallback(err error) bool {
+	return !errors.Is(err, ErrCircuitOpen) && !errors.Is(err, ErrBlocked) && !errors.Is(err, ErrDisallowedByRobots)
+}
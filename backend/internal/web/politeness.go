@@ -0,0 +1,174 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrDisallowedByRobots indica que o robots.txt da loja proíbe a página.
+var ErrDisallowedByRobots = errors.New("página bloqueada pelo robots.txt da loja")
+
+// PolitenessConfig define o ritmo de requisições por loja.
+type PolitenessConfig struct {
+	// RequestsPerMinute e Burst formam o token bucket de cada domínio.
+	RequestsPerMinute float64
+	Burst             int
+	// RespectRobots liga a leitura do robots.txt (Disallow e Crawl-delay).
+	RespectRobots bool
+	RobotsTTL     time.Duration
+	// RobotsAgent é o nome procurado nos grupos User-agent do robots.txt.
+	RobotsAgent string
+}
+
+func DefaultPolitenessConfig() PolitenessConfig {
+	return PolitenessConfig{
+		RequestsPerMinute: 12,
+		Burst:             1,
+		RobotsTTL:         24 * time.Hour,
+		RobotsAgent:       "price-analyzer",
+	}
+}
+
+// PolitenessConfigFromEnv aplica as variáveis SCRAPER_* sobre os valores padrão.
+func PolitenessConfigFromEnv() PolitenessConfig {
+	cfg := DefaultPolitenessConfig()
+	cfg.RequestsPerMinute = float64(envInt("SCRAPER_REQUESTS_PER_MINUTE", int(cfg.RequestsPerMinute)))
+	cfg.Burst = envInt("SCRAPER_BURST", cfg.Burst)
+	cfg.RespectRobots = os.Getenv("SCRAPER_RESPECT_ROBOTS") == "true"
+	cfg.RobotsTTL = envDuration("SCRAPER_ROBOTS_TTL", cfg.RobotsTTL)
+	if agent := os.Getenv("SCRAPER_ROBOTS_AGENT"); agent != "" {
+		cfg.RobotsAgent = agent
+	}
+	return cfg
+}
+
+// hostState guarda o robots.txt de um hostname; o ritmo é controlado por
+// loja (produto.mercadolivre.com.br e www.mercadolivre.com.br dividem o
+// mesmo limiter).
+type hostState struct {
+	robotsMu      sync.Mutex
+	robots        robotsRules
+	robotsFetched time.Time
+}
+
+type politeness struct {
+	mu       sync.Mutex
+	cfg      PolitenessConfig
+	hosts    map[string]*hostState
+	limiters map[string]*rate.Limiter
+}
+
+var polite = &politeness{
+	cfg:      DefaultPolitenessConfig(),
+	hosts:    map[string]*hostState{},
+	limiters: map[string]*rate.Limiter{},
+}
+
+// ConfigurePoliteness troca os limites por domínio. Os limiters já criados
+// são descartados.
+func ConfigurePoliteness(cfg PolitenessConfig) {
+	polite.mu.Lock()
+	defer polite.mu.Unlock()
+	polite.cfg = cfg
+	polite.hosts = map[string]*hostState{}
+	polite.limiters = map[string]*rate.Limiter{}
+}
+
+func (p *politeness) host(host string) (*hostState, *rate.Limiter, PolitenessConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	host = normalizeHost(host)
+	h, ok := p.hosts[host]
+	if !ok {
+		h = &hostState{}
+		p.hosts[host] = h
+	}
+
+	store := storeDomain(host)
+	l, ok := p.limiters[store]
+	if !ok {
+		l = rate.NewLimiter(perMinute(p.cfg.RequestsPerMinute), max(p.cfg.Burst, 1))
+		p.limiters[store] = l
+	}
+	return h, l, p.cfg
+}
+
+// wait bloqueia até a loja poder receber mais uma requisição e confere o
+// robots.txt quando configurado.
+func (p *politeness) wait(ctx context.Context, u *url.URL) error {
+	h, limiter, cfg := p.host(u.Hostname())
+
+	if cfg.RespectRobots {
+		rules := h.rules(ctx, u, cfg)
+		path := u.EscapedPath()
+		if u.RawQuery != "" {
+			path += "?" + u.RawQuery
+		}
+		if !rules.allowed(path) {
+			return ErrDisallowedByRobots
+		}
+		if rules.crawlDelay > 0 {
+			if delayed := rate.Every(rules.crawlDelay); delayed < limiter.Limit() {
+				limiter.SetLimit(delayed)
+			}
+		}
+	}
+
+	return limiter.Wait(ctx)
+}
+
+// rules devolve o robots.txt em cache, buscando de novo depois do TTL. Falhas
+// de rede ou 404 resultam em nenhuma regra, como manda o padrão.
+func (h *hostState) rules(ctx context.Context, u *url.URL, cfg PolitenessConfig) robotsRules {
+	h.robotsMu.Lock()
+	defer h.robotsMu.Unlock()
+
+	if !h.robotsFetched.IsZero() && time.Since(h.robotsFetched) < cfg.RobotsTTL {
+		return h.robots
+	}
+
+	h.robots = robotsRules{}
+	h.robotsFetched = time.Now()
+
+	robotsURL := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	req, err := http.NewRequestWithContext(ctx, "GET", robotsURL.String(), nil)
+	if err != nil {
+		return h.robots
+	}
+	client, clientCfg := sharedClient()
+	req.Header.Set("User-Agent", clientCfg.UserAgent)
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return h.robots
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return h.robots
+	}
+	body, closeBody, err := decodeBody(res)
+	if err != nil {
+		return h.robots
+	}
+	defer closeBody()
+
+	h.robots = parseRobots(body, cfg.RobotsAgent)
+	return h.robots
+}
+
+func perMinute(n float64) rate.Limit {
+	if n <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(n / 60)
+}
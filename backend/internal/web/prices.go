@@ -0,0 +1,99 @@
+package web
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+
+	"price-analyzer-backend/internal/money"
+)
+
+// PriceKind escolhe qual dos preços exibidos pela loja é comparado com o
+// preço-alvo do usuário.
+type PriceKind string
+
+const (
+	PriceDefault PriceKind = "default"
+	PriceCash    PriceKind = "cash"
+	PriceCard    PriceKind = "card"
+)
+
+func (k PriceKind) Valid() bool {
+	return k == PriceDefault || k == PriceCash || k == PriceCard
+}
+
+// PriceFor devolve o preço do tipo pedido, caindo para o preço principal
+// quando a loja não exibe aquele tipo separadamente.
+func (r ScrapeResult) PriceFor(kind PriceKind) money.Amount {
+	switch kind {
+	case PriceCash:
+		if r.CashPrice > 0 {
+			return r.CashPrice
+		}
+	case PriceCard:
+		if r.CardPrice > 0 {
+			return r.CardPrice
+		}
+		if r.Installments > 0 && r.InstallmentPrice > 0 {
+			return r.InstallmentPrice.Mul(r.Installments)
+		}
+	}
+	return r.Price
+}
+
+// DiscountPercent é o desconto anunciado pela loja sobre o preço "de".
+func (r ScrapeResult) DiscountPercent() float64 {
+	if r.ListPrice <= 0 || r.Price <= 0 || r.Price >= r.ListPrice {
+		return 0
+	}
+	return math.Round((1-r.Price.Float64()/r.ListPrice.Float64())*1000) / 10
+}
+
+var (
+	listPriceRe   = regexp.MustCompile(`(?i)\bde\s*R\$\s*(\d{1,3}(?:\.\d{3})*(?:,\d{2})?)\s*(?:por|por apenas)\s*R\$\s*(\d{1,3}(?:\.\d{3})*(?:,\d{2})?)`)
+	installmentRe = regexp.MustCompile(`(?i)(\d{1,2})\s*x\s*(?:de\s*)?(?:R\$\s*)?(\d{1,3}(?:\.\d{3})*(?:,\d{2})?)`)
+	cashPriceRe   = regexp.MustCompile(`(?i)R\$\s*(\d{1,3}(?:\.\d{3})*(?:,\d{2})?)[^$]{0,40}?\b(?:pix|boleto)\b`)
+)
+
+// listPriceFromText lê o padrão "de R$ X por R$ Y" e devolve os dois valores.
+func listPriceFromText(text string) (money.Amount, money.Amount) {
+	m := listPriceRe.FindStringSubmatch(text)
+	if m == nil {
+		return 0, 0
+	}
+	return parsePrice(m[1], money.LocalePtBR), parsePrice(m[2], money.LocalePtBR)
+}
+
+// parseInstallments lê planos como "10x de R$ 235,29 sem juros".
+func parseInstallments(text string) (int, money.Amount) {
+	m := installmentRe.FindStringSubmatch(text)
+	if m == nil {
+		return 0, 0
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n < 2 {
+		return 0, 0
+	}
+	return n, parsePrice(m[2], money.LocalePtBR)
+}
+
+// cashPriceFromText procura um valor seguido de "no Pix" / "no boleto".
+func cashPriceFromText(text string) money.Amount {
+	m := cashPriceRe.FindStringSubmatch(text)
+	if m == nil {
+		return 0
+	}
+	return parsePrice(m[1], money.LocalePtBR)
+}
+
+// fromMinorUnits converte um inteiro com minor casas decimais implícitas
+// (12990 com 2 casas = 129,90) para Amount.
+func fromMinorUnits(n int64, minor int) money.Amount {
+	for ; minor > 2; minor-- {
+		n /= 10
+	}
+	for ; minor < 2; minor++ {
+		n *= 10
+	}
+	return money.FromCents(n)
+}
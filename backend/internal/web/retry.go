@@ -0,0 +1,323 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatusError é uma resposta HTTP diferente de 200.
+type StatusError struct {
+	Code       int
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("site retornou status: %d", e.Code)
+}
+
+// ErrCircuitOpen indica que a loja está falhando seguidamente e as
+// requisições estão suspensas por um tempo.
+var ErrCircuitOpen = errors.New("loja temporariamente suspensa após falhas seguidas")
+
+// RetryConfig controla as novas tentativas e o circuit breaker por loja.
+type RetryConfig struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+
+	// Falhas seguidas que abrem o circuito e por quanto tempo ele fica aberto.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxAttempts:      3,
+		BaseDelay:        2 * time.Second,
+		MaxDelay:         time.Minute,
+		BreakerThreshold: 5,
+		BreakerCooldown:  15 * time.Minute,
+	}
+}
+
+// RetryConfigFromEnv aplica as variáveis SCRAPER_* sobre os valores padrão.
+func RetryConfigFromEnv() RetryConfig {
+	cfg := DefaultRetryConfig()
+	cfg.MaxAttempts = envInt("SCRAPER_MAX_ATTEMPTS", cfg.MaxAttempts)
+	cfg.BaseDelay = envDuration("SCRAPER_RETRY_BASE_DELAY", cfg.BaseDelay)
+	cfg.MaxDelay = envDuration("SCRAPER_RETRY_MAX_DELAY", cfg.MaxDelay)
+	cfg.BreakerThreshold = envInt("SCRAPER_BREAKER_THRESHOLD", cfg.BreakerThreshold)
+	cfg.BreakerCooldown = envDuration("SCRAPER_BREAKER_COOLDOWN", cfg.BreakerCooldown)
+	return cfg
+}
+
+var (
+	retryMu     sync.RWMutex
+	retryConfig = DefaultRetryConfig()
+)
+
+func ConfigureRetries(cfg RetryConfig) {
+	retryMu.Lock()
+	defer retryMu.Unlock()
+	retryConfig = cfg
+}
+
+func currentRetryConfig() RetryConfig {
+	retryMu.RLock()
+	defer retryMu.RUnlock()
+	return retryConfig
+}
+
+// withRetries executa fn com novas tentativas para falhas transitórias,
+// passando pelo circuit breaker da loja.
+func withRetries(ctx context.Context, host string, fn func() error) error {
+	cfg := currentRetryConfig()
+	b := breakerFor(host)
+
+	var err error
+	for attempt := 0; attempt < max(cfg.MaxAttempts, 1); attempt++ {
+		if !b.allow(cfg) {
+			return ErrCircuitOpen
+		}
+
+		err = fn()
+		if err == nil {
+			b.success(host)
+			return nil
+		}
+
+		// Bloqueio não adianta repetir agora, mas conta para o circuito: é
+		// justamente a loja que precisamos parar de insistir.
+		if errors.Is(err, ErrBlocked) || StatusCode(err) == http.StatusForbidden {
+			b.failure(host, cfg)
+			return err
+		}
+
+		retry, wait := classifyRetry(err)
+		if !retry {
+			// A loja respondeu (404, página sem preço...): está de pé. Sem
+			// resposta (robots, cancelamento), o teste fica sem veredito.
+			if errors.Is(err, ErrDisallowedByRobots) || ctx.Err() != nil {
+				b.abandon()
+			} else {
+				b.success(host)
+			}
+			return err
+		}
+		b.failure(host, cfg)
+
+		if attempt == cfg.MaxAttempts-1 {
+			break
+		}
+		delay := backoff(cfg, attempt)
+		if wait > delay {
+			if wait > cfg.MaxDelay {
+				break
+			}
+			delay = wait
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	return err
+}
+
+// classifyRetry diz se o erro é transitório (timeout, falha de rede, 5xx ou
+// 429) e quanto a loja pediu para esperar via Retry-After.
+func classifyRetry(err error) (bool, time.Duration) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		retry := statusErr.Code == http.StatusTooManyRequests || statusErr.Code >= 500
+		return retry, statusErr.RetryAfter
+	}
+
+	if errors.Is(err, ErrDisallowedByRobots) || errors.Is(err, ErrCircuitOpen) {
+		return false, 0
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true, 0
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF) || strings.Contains(err.Error(), "connection reset") {
+		return true, 0
+	}
+	return false, 0
+}
+
+// backoff é exponencial com jitter: metade fixa, metade aleatória.
+func backoff(cfg RetryConfig, attempt int) time.Duration {
+	d := cfg.BaseDelay << attempt
+	if d <= 0 || d > cfg.MaxDelay {
+		d = cfg.MaxDelay
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter entende os dois formatos do cabeçalho: segundos ou data HTTP.
+func parseRetryAfter(v string) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// BreakerState é o estado do circuito de uma loja.
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// BreakerStatus é o retrato do circuito de uma loja para a API.
+type BreakerStatus struct {
+	Host        string       `json:"host"`
+	State       BreakerState `json:"state"`
+	Failures    int          `json:"consecutive_failures"`
+	OpenedAt    *time.Time   `json:"opened_at,omitempty"`
+	RetryAfter  *time.Time   `json:"retry_after,omitempty"`
+	LastFailure *time.Time   `json:"last_failure,omitempty"`
+}
+
+type breaker struct {
+	mu          sync.Mutex
+	state       BreakerState
+	failures    int
+	openedAt    time.Time
+	lastFailure time.Time
+	cooldown    time.Duration
+	// probing marca que a única tentativa de teste do half-open está em curso.
+	probing bool
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*breaker{}
+)
+
+func breakerFor(host string) *breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	host = normalizeHost(host)
+	b, ok := breakers[host]
+	if !ok {
+		b = &breaker{state: BreakerClosed}
+		breakers[host] = b
+	}
+	return b
+}
+
+// allow deixa passar uma única tentativa de teste depois do cooldown
+// (half-open); as concorrentes esperam o resultado dela.
+func (b *breaker) allow(cfg RetryConfig) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerClosed:
+		return true
+	case BreakerOpen:
+		if time.Since(b.openedAt) < cfg.BreakerCooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	}
+	if b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// abandon devolve o teste sem veredito (a requisição nem chegou à loja): o
+// circuito volta a aberto e o próximo chamador testa de novo.
+func (b *breaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		b.state = BreakerOpen
+	}
+	b.probing = false
+}
+
+func (b *breaker) success(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != BreakerClosed {
+		log.Printf("🟢 Circuito de %s fechado novamente.", host)
+	}
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *breaker) failure(host string, cfg RetryConfig) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.lastFailure = time.Now()
+	b.cooldown = cfg.BreakerCooldown
+	b.probing = false
+
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= cfg.BreakerThreshold) {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+		log.Printf("🔴 Circuito de %s aberto após %d falhas seguidas. Pausando por %s.", host, b.failures, cfg.BreakerCooldown)
+	}
+}
+
+// BreakerStatuses lista o estado do circuito de cada loja já acessada.
+func BreakerStatuses() []BreakerStatus {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	out := make([]BreakerStatus, 0, len(breakers))
+	for host, b := range breakers {
+		b.mu.Lock()
+		st := BreakerStatus{Host: host, State: b.state, Failures: b.failures}
+		if !b.lastFailure.IsZero() {
+			last := b.lastFailure
+			st.LastFailure = &last
+		}
+		if b.state == BreakerOpen {
+			opened, retry := b.openedAt, b.openedAt.Add(b.cooldown)
+			st.OpenedAt, st.RetryAfter = &opened, &retry
+		}
+		b.mu.Unlock()
+		out = append(out, st)
+	}
+	return out
+}
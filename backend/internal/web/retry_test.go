@@ -0,0 +1,81 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBreakerHalfOpenAllowsSingleProbe(t *testing.T) {
+	cfg := RetryConfig{MaxAttempts: 1, BreakerThreshold: 1, BreakerCooldown: time.Millisecond}
+	b := &breaker{state: BreakerClosed}
+	b.failure("loja.test", cfg)
+	time.Sleep(2 * time.Millisecond)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if b.allow(cfg) {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 1 {
+		t.Fatalf("half-open deixou passar %d tentativas, queria 1", allowed)
+	}
+}
+
+func TestWithRetriesSettlesHalfOpenOnNonRetryableResponse(t *testing.T) {
+	old := currentRetryConfig()
+	defer ConfigureRetries(old)
+	ConfigureRetries(RetryConfig{MaxAttempts: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond,
+		BreakerThreshold: 1, BreakerCooldown: time.Millisecond})
+
+	host := "half-open-404.test"
+	fail := func() error { return &StatusError{Code: http.StatusServiceUnavailable} }
+	notFound := func() error { return &StatusError{Code: http.StatusNotFound} }
+
+	withRetries(context.Background(), host, fail)
+	if st := breakerFor(host).state; st != BreakerOpen {
+		t.Fatalf("estado após falha = %s, queria open", st)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	withRetries(context.Background(), host, notFound)
+	if st := breakerFor(host).state; st != BreakerClosed {
+		t.Fatalf("estado após 404 no half-open = %s, queria closed", st)
+	}
+}
+
+func TestBreakerStatusOmitsEmptyTimes(t *testing.T) {
+	breakerFor("status-vazio.test")
+
+	for _, st := range BreakerStatuses() {
+		if st.Host != "status-vazio.test" {
+			continue
+		}
+		raw, err := json.Marshal(st)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(raw), "0001-01-01") {
+			t.Fatalf("status com data zerada: %s", raw)
+		}
+		return
+	}
+	t.Fatal("breaker não listado")
+}
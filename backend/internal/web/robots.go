@@ -0,0 +1,113 @@
+package web
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsRules é o grupo do robots.txt que se aplica ao nosso user-agent.
+type robotsRules struct {
+	allow      []string
+	disallow   []string
+	crawlDelay time.Duration
+}
+
+// parseRobots lê um robots.txt e devolve as regras do grupo mais específico
+// para agent, caindo para o grupo "*".
+func parseRobots(r io.Reader, agent string) robotsRules {
+	agent = strings.ToLower(agent)
+
+	var (
+		specific, wildcard robotsRules
+		hasSpecific        bool
+		current            []*robotsRules
+		inRules            bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Um user-agent depois de regras inicia um novo grupo.
+			if inRules {
+				current, inRules = nil, false
+			}
+			ua := strings.ToLower(value)
+			switch {
+			case ua == "*":
+				current = append(current, &wildcard)
+			case agent != "" && strings.Contains(agent, ua):
+				hasSpecific = true
+				current = append(current, &specific)
+			}
+		case "allow", "disallow", "crawl-delay":
+			inRules = true
+			for _, g := range current {
+				switch key {
+				case "allow":
+					if value != "" {
+						g.allow = append(g.allow, value)
+					}
+				case "disallow":
+					if value != "" {
+						g.disallow = append(g.disallow, value)
+					}
+				case "crawl-delay":
+					if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+						g.crawlDelay = time.Duration(secs * float64(time.Second))
+					}
+				}
+			}
+		}
+	}
+
+	if hasSpecific {
+		return specific
+	}
+	return wildcard
+}
+
+// allowed aplica a regra mais longa que casa com o caminho; em empate,
+// Allow vence, como no padrão do Google.
+func (r robotsRules) allowed(path string) bool {
+	best, allow := -1, true
+	for _, p := range r.disallow {
+		if robotsMatch(p, path) && len(p) > best {
+			best, allow = len(p), false
+		}
+	}
+	for _, p := range r.allow {
+		if robotsMatch(p, path) && len(p) >= best {
+			best, allow = len(p), true
+		}
+	}
+	return allow
+}
+
+// robotsMatch entende os curingas "*" e a âncora "$" do robots.txt.
+func robotsMatch(pattern, path string) bool {
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	if strings.HasSuffix(expr, `\$`) {
+		expr = strings.TrimSuffix(expr, `\$`) + "$"
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(path)
+}
@@ -1,109 +1,221 @@
 package web
 
 import (
-	"crypto/tls"
-	"fmt"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
 	"net/http"
-	"strconv"
+	"net/url"
 	"strings"
-	"time"
 
 	"github.com/PuerkitoBio/goquery"
+
+	"price-analyzer-backend/internal/money"
+	"price-analyzer-backend/internal/snapshot"
 )
 
-func ScrapeProduct(url string) (string, string, float64, error) {
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+// Páginas maiores que isso são truncadas; nenhuma página de produto chega perto.
+const maxPageSize = 10 << 20
+
+func ScrapeProduct(rawURL string) (ScrapeResult, error) {
+	return scrape(rawURL, true)
+}
+
+// PreviewProduct roda a mesma extração de ScrapeProduct sem guardar o
+// snapshot, para mostrar ao usuário o que será monitorado.
+func PreviewProduct(rawURL string) (ScrapeResult, error) {
+	return scrape(rawURL, false)
+}
+
+func scrape(rawURL string, keepSnapshot bool) (ScrapeResult, error) {
+	ctx := context.Background()
+	host := StoreHost(rawURL)
+
+	// Lojas de plataforma já conhecida vão direto à API; o HTML fica de
+	// reserva para quando ela não encontrar o produto.
+	p, known := knownPlatforms.get(host)
+	if known {
+		r, err := fetchFromPlatform(ctx, p, rawURL)
+		if err == nil {
+			return withPlaceholders(r), nil
+		}
+		if !platformFallback(err) {
+			return ScrapeResult{}, err
+		}
+		log.Printf("⚠️ API %s falhou para %s, lendo o HTML: %v", p.Name(), rawURL, err)
 	}
-	client := &http.Client{Transport: tr, Timeout: 15 * time.Second}
 
-	req, err := http.NewRequest("GET", url, nil)
+	var (
+		doc     *goquery.Document
+		pageURL *url.URL
+		html    []byte
+	)
+	err := withRetries(ctx, host, func() error {
+		var err error
+		doc, pageURL, html, err = fetchPage(ctx, rawURL)
+		return err
+	})
 	if err != nil {
-		return "", "", 0, err
+		return ScrapeResult{}, err
 	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en-US;q=0.8,en;q=0.7")
-	req.Header.Set("Referer", "https://www.google.com/")
-	req.Header.Set("Upgrade-Insecure-Requests", "1")
+	r := extractProduct(doc, pageURL)
+
+	// Lojas com definição própria continuam nos seletores, mesmo que a
+	// página tenha marcas de uma plataforma.
+	if !known && ExtractorFor(pageURL.Hostname()) == fallback {
+		if p := detectPlatform(doc); p != nil {
+			log.Printf("🧩 %s detectada como loja %s.", host, p.Name())
+			knownPlatforms.set(p, host)
+			if api, err := fetchFromPlatform(ctx, p, pageURL.String()); err == nil {
+				api.fill(r)
+				r = api
+			}
+		}
+	}
 
-	res, err := client.Do(req)
+	// O snapshot só serve ao reprocessamento se o resultado veio do HTML.
+	if keepSnapshot && r.Strategy != StrategyPlatformAPI {
+		if hash, err := snapshot.Save(html); err == nil {
+			r.SnapshotHash = hash
+		} else if !errors.Is(err, snapshot.ErrDisabled) {
+			log.Println("⚠️ Erro ao salvar snapshot:", err)
+		}
+	}
+
+	return withPlaceholders(r), nil
+}
+
+func withPlaceholders(r ScrapeResult) ScrapeResult {
+	if r.ImageURL == "" {
+		r.ImageURL = "https://placehold.co/600x400?text=Sem+Imagem"
+	}
+
+	if r.Title == "" {
+		r.Title = "Produto Desconhecido"
+	}
+
+	return r
+}
+
+// ExtractHTML roda os extractors atuais sobre um HTML já guardado, como se
+// ele tivesse acabado de ser baixado de pageURL.
+func ExtractHTML(html []byte, pageURL string) (ScrapeResult, error) {
+	u, err := url.Parse(pageURL)
 	if err != nil {
-		return "", "", 0, err
+		return ScrapeResult{}, err
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+	if err != nil {
+		return ScrapeResult{}, &ScrapeError{Kind: ErrKindParse, Err: err}
+	}
+	return extractProduct(doc, u), nil
+}
+
+// fetchPage faz uma única requisição, respeitando o ritmo da loja, e devolve
+// a página já decodificada junto com a URL final (após redirecionamentos) e
+// o HTML bruto.
+func fetchPage(ctx context.Context, rawURL string) (*goquery.Document, *url.URL, []byte, error) {
+	res, err := doRequest(ctx, rawURL, "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8")
+	if err != nil {
+		return nil, nil, nil, err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != 200 {
-		return "", "", 0, fmt.Errorf("site retornou status: %d", res.StatusCode)
+		return nil, nil, nil, &StatusError{Code: res.StatusCode, RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"))}
 	}
 
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+	body, closeBody, err := decodeBody(res)
 	if err != nil {
-		return "", "", 0, err
+		return nil, nil, nil, &ScrapeError{Kind: ErrKindParse, Err: err}
 	}
+	defer closeBody()
 
-	image, _ := doc.Find("meta[property='og:image']").Attr("content")
-	if image == "" {
-		image, _ = doc.Find("meta[name='twitter:image']").Attr("content")
+	html, err := io.ReadAll(io.LimitReader(body, maxPageSize))
+	if err != nil {
+		return nil, nil, nil, classifyReadError(err)
 	}
 
-	title, _ := doc.Find("meta[property='og:title']").Attr("content")
-	if title == "" {
-		title = doc.Find("title").Text()
+	if err := detectBlockPage(html, res.Request.URL); err != nil {
+		return nil, nil, nil, err
 	}
-	title = strings.TrimSpace(title)
 
-	price := 0.0
-	
-	metaPrice, exists := doc.Find("meta[itemprop='price']").Attr("content")
-	if exists {
-		if p, err := strconv.ParseFloat(metaPrice, 64); err == nil {
-			price = p
-		}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+	if err != nil {
+		return nil, nil, nil, classifyReadError(err)
 	}
+	return doc, res.Request.URL, html, nil
+}
 
-	if price == 0 {
-		if strings.Contains(url, "mercadolivre.com.br") {
-			priceStr := doc.Find(".andes-money-amount__fraction").First().Text()
-			price = cleanPrice(priceStr)
-		} else if strings.Contains(url, "amazon.com.br") {
-			priceStr := doc.Find(".a-price-whole").First().Text()
-			price = cleanPrice(priceStr)
-		} else if strings.Contains(url, "kabum.com.br") {
-			priceStr := doc.Find(".finalPrice").First().Text()
-			price = cleanPrice(priceStr)
-		}
+// doRequest monta a requisição com os cabeçalhos de navegador, espera a vez
+// da loja e a executa. O chamador fecha o corpo da resposta.
+func doRequest(ctx context.Context, rawURL, accept string) (*http.Response, error) {
+	client, cfg := sharedClient()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", cfg.UserAgent)
+	req.Header.Set("Accept", accept)
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+	req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en-US;q=0.8,en;q=0.7")
+	req.Header.Set("Referer", "https://www.google.com/")
+	req.Header.Set("Upgrade-Insecure-Requests", "1")
+
+	if err := polite.wait(ctx, req.URL); err != nil {
+		return nil, err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, &ScrapeError{Kind: ErrKindNetwork, Err: err}
+	}
+	return res, nil
+}
+
+// fetchJSON busca uma API pública da loja e decodifica a resposta em v.
+// Números são mantidos como json.Number para não perder centavos.
+func fetchJSON(ctx context.Context, rawURL string, v interface{}) error {
+	res, err := doRequest(ctx, rawURL, "application/json")
+	if err != nil {
+		return err
 	}
+	defer res.Body.Close()
 
-	if image == "" {
-		image = "https://placehold.co/600x400?text=Sem+Imagem"
+	// A busca do VTEX responde 206 quando pagina o resultado.
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+		return &StatusError{Code: res.StatusCode, RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"))}
 	}
 
-	if title == "" {
-		title = "Produto Desconhecido"
+	body, closeBody, err := decodeBody(res)
+	if err != nil {
+		return &ScrapeError{Kind: ErrKindParse, Err: err}
 	}
+	defer closeBody()
 
-	return title, image, price, nil
+	dec := json.NewDecoder(io.LimitReader(body, maxPageSize))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return classifyReadError(err)
+	}
+	return nil
 }
 
-func cleanPrice(raw string) float64 {
-	if raw == "" {
-		return 0.0
-	}
-	clean := strings.ReplaceAll(raw, ".", "")
-	clean = strings.ReplaceAll(clean, ",", ".")
-	
-	var numberBuilder strings.Builder
-	for _, r := range clean {
-		if (r >= '0' && r <= '9') || r == '.' {
-			numberBuilder.WriteRune(r)
-		}
+// parsePrice lê um preço exibido na página com a convenção de separadores
+// da loja. Texto sem valor reconhecível resulta em zero.
+func parsePrice(raw string, loc money.Locale) money.Amount {
+	if strings.TrimSpace(raw) == "" {
+		return 0
 	}
-	
-	val, err := strconv.ParseFloat(numberBuilder.String(), 64)
+	p, err := money.ParseText(raw, loc)
 	if err != nil {
-		return 0.0
+		return 0
 	}
-	return val
-}
\ No newline at end of file
+	return p.Amount
+}
@@ -0,0 +1,148 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"price-analyzer-backend/internal/money"
+)
+
+// shopifyPlatform lê produtos de lojas Shopify pelos endpoints públicos
+// /products/<handle>.js (com estoque) e /products/<handle>.json.
+type shopifyPlatform struct{}
+
+func init() {
+	RegisterPlatform(shopifyPlatform{})
+	knownPlatforms.set(shopifyPlatform{}, "*.myshopify.com")
+}
+
+func (shopifyPlatform) Name() string { return "shopify" }
+
+func (shopifyPlatform) Detect(doc *goquery.Document) bool {
+	return doc.Find("script[src*='cdn.shopify.com'], link[href*='cdn.shopify.com'], meta[name='shopify-checkout-api-token'], meta[name='shopify-digital-wallet']").Length() > 0
+}
+
+var shopifyHandleRe = regexp.MustCompile(`/products/([^/?#.]+)`)
+
+func (shopifyPlatform) Fetch(ctx context.Context, productURL *url.URL) (ScrapeResult, error) {
+	m := shopifyHandleRe.FindStringSubmatch(productURL.Path)
+	if m == nil {
+		return ScrapeResult{}, &ScrapeError{Kind: ErrKindNotFound, Err: errors.New("URL fora do padrão /products/<handle> do Shopify")}
+	}
+	base := url.URL{Scheme: productURL.Scheme, Host: productURL.Host, Path: "/products/" + m[1]}
+	variant := productURL.Query().Get("variant")
+
+	// O .js informa o estoque de cada variante; algumas lojas o desativam e
+	// só o .json responde.
+	base.Path += ".js"
+	var p shopifyProduct
+	err := fetchJSON(ctx, base.String(), &p)
+	if err == nil {
+		return shopifyResult(p, variant, true), nil
+	}
+	if StatusCode(err) != 404 {
+		return ScrapeResult{}, err
+	}
+
+	base.Path = strings.TrimSuffix(base.Path, ".js") + ".json"
+	var wrapped struct {
+		Product shopifyProduct `json:"product"`
+	}
+	if err := fetchJSON(ctx, base.String(), &wrapped); err != nil {
+		return ScrapeResult{}, err
+	}
+	return shopifyResult(wrapped.Product, variant, false), nil
+}
+
+// shopifyProduct cobre os dois formatos: no .js os preços vêm em centavos
+// inteiros e as imagens como texto; no .json, em reais ("19.90") e objetos.
+type shopifyProduct struct {
+	Title    string            `json:"title"`
+	Images   []json.RawMessage `json:"images"`
+	Variants []shopifyVariant  `json:"variants"`
+}
+
+type shopifyVariant struct {
+	ID             json.Number     `json:"id"`
+	SKU            string          `json:"sku"`
+	Barcode        string          `json:"barcode"`
+	Price          json.RawMessage `json:"price"`
+	CompareAtPrice json.RawMessage `json:"compare_at_price"`
+	Available      *bool           `json:"available"`
+	FeaturedImage  *struct {
+		Src string `json:"src"`
+	} `json:"featured_image"`
+}
+
+func shopifyResult(p shopifyProduct, variantID string, cents bool) ScrapeResult {
+	r := ScrapeResult{Title: p.Title}
+	if len(p.Variants) == 0 {
+		return r
+	}
+
+	v := p.Variants[0]
+	for _, it := range p.Variants {
+		if variantID != "" && it.ID.String() == variantID {
+			v = it
+			break
+		}
+		if variantID == "" && it.Available != nil && *it.Available {
+			v = it
+			break
+		}
+	}
+
+	r.Price = shopifyAmount(v.Price, cents)
+	r.SKU = v.SKU
+	r.GTIN = v.Barcode
+	if list := shopifyAmount(v.CompareAtPrice, cents); list > r.Price {
+		r.ListPrice = list
+	}
+
+	r.Availability = AvailabilityUnknown
+	if v.Available != nil {
+		r.Availability = AvailabilityOutOfStock
+		if *v.Available {
+			r.Availability = AvailabilityInStock
+		}
+	}
+
+	if v.FeaturedImage != nil && v.FeaturedImage.Src != "" {
+		r.ImageURL = v.FeaturedImage.Src
+	} else if len(p.Images) > 0 {
+		var src interface{}
+		if json.Unmarshal(p.Images[0], &src) == nil {
+			r.ImageURL = jsonImage(src)
+			if m, ok := src.(map[string]interface{}); ok && r.ImageURL == "" {
+				r.ImageURL = jsonString(m["src"])
+			}
+		}
+	}
+	if strings.HasPrefix(r.ImageURL, "//") {
+		r.ImageURL = "https:" + r.ImageURL
+	}
+	return r
+}
+
+func shopifyAmount(raw json.RawMessage, cents bool) money.Amount {
+	s := strings.Trim(string(raw), `"`)
+	if s == "" || s == "null" {
+		return 0
+	}
+	if cents {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0
+		}
+		return money.FromCents(n)
+	}
+	a, _ := money.Parse(s)
+	return a
+}
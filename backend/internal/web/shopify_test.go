@@ -0,0 +1,151 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShopifyResult(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "shopify", "product.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var js shopifyProduct
+	if err := json.Unmarshal(raw, &js); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err = os.ReadFile(filepath.Join("testdata", "shopify", "product.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wrapped struct {
+		Product shopifyProduct `json:"product"`
+	}
+	if err := json.Unmarshal(raw, &wrapped); err != nil {
+		t.Fatal(err)
+	}
+
+	const title = "Tênis Casual Couro Legítimo"
+	const frontImage = "https://cdn.shopify.com/s/files/1/0612/3456/7890/products/tenis-casual-frente.jpg?v=1700000000"
+
+	cases := []struct {
+		name    string
+		product shopifyProduct
+		variant string
+		cents   bool
+		want    ScrapeResult
+	}{
+		{
+			name:    ".js sem variant pega a primeira disponível",
+			product: js,
+			cents:   true,
+			want: ScrapeResult{
+				Title:        title,
+				ImageURL:     frontImage,
+				Price:        28990,
+				ListPrice:    39990,
+				SKU:          "TCC-40",
+				GTIN:         "7890000000407",
+				Availability: AvailabilityInStock,
+			},
+		},
+		{
+			name:    ".js com variant esgotada",
+			product: js,
+			variant: "43210000000001",
+			cents:   true,
+			want: ScrapeResult{
+				Title:        title,
+				ImageURL:     frontImage,
+				Price:        28990,
+				ListPrice:    39990,
+				SKU:          "TCC-39",
+				GTIN:         "7890000000391",
+				Availability: AvailabilityOutOfStock,
+			},
+		},
+		{
+			name:    ".js com variant de imagem própria",
+			product: js,
+			variant: "43210000000003",
+			cents:   true,
+			want: ScrapeResult{
+				Title:        title,
+				ImageURL:     "https://cdn.shopify.com/s/files/1/0612/3456/7890/products/tenis-casual-preto.jpg?v=1700000001",
+				Price:        31990,
+				SKU:          "TCC-41P",
+				GTIN:         "7890000000414",
+				Availability: AvailabilityInStock,
+			},
+		},
+		{
+			name:    ".json em reais e sem estoque",
+			product: wrapped.Product,
+			want: ScrapeResult{
+				Title:        title,
+				ImageURL:     frontImage,
+				Price:        28990,
+				ListPrice:    39990,
+				SKU:          "TCC-39",
+				GTIN:         "7890000000391",
+				Availability: AvailabilityUnknown,
+			},
+		},
+		{
+			name:    ".json com variant",
+			product: wrapped.Product,
+			variant: "43210000000003",
+			want: ScrapeResult{
+				Title:        title,
+				ImageURL:     frontImage,
+				Price:        31990,
+				SKU:          "TCC-41P",
+				GTIN:         "7890000000414",
+				Availability: AvailabilityUnknown,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := shopifyResult(c.product, c.variant, c.cents); got != c.want {
+				t.Errorf("shopifyResult =\n%+v\nqueria\n%+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestShopifyFetchFallsBackToJSON(t *testing.T) {
+	ConfigurePoliteness(PolitenessConfig{RequestsPerMinute: 6000, Burst: 10})
+	t.Cleanup(func() { ConfigurePoliteness(DefaultPolitenessConfig()) })
+
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path != "/products/tenis-casual-couro.json" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, filepath.Join("testdata", "shopify", "product.json"))
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL + "/collections/masculino/products/tenis-casual-couro?variant=43210000000003")
+	r, err := shopifyPlatform{}.Fetch(context.Background(), u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 || paths[0] != "/products/tenis-casual-couro.js" {
+		t.Errorf("requisições = %v, queria o .js e depois o .json", paths)
+	}
+	if r.SKU != "TCC-41P" || r.Price != 31990 {
+		t.Errorf("resultado = %s %v, queria TCC-41P 319.90", r.SKU, r.Price)
+	}
+}
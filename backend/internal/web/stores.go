@@ -0,0 +1,495 @@
+package web
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"price-analyzer-backend/internal/money"
+)
+
+// StoreDefinition descreve em JSON como ler as páginas de uma loja, sem
+// precisar de código Go. Os campos de Fields só completam o que o extractor
+// genérico (meta tags) deixou vazio, e cada campo aceita várias regras,
+// tentadas em ordem até uma dar resultado.
+type StoreDefinition struct {
+	Name   string   `json:"name"`
+	Hosts  []string `json:"hosts"`
+	Locale string   `json:"locale"`
+
+	// SkipGeneric desliga a leitura das meta tags antes das regras da loja.
+	SkipGeneric bool `json:"skip_generic"`
+
+	Canonical    []CanonicalRule   `json:"canonical"`
+	Fields       FieldRules        `json:"fields"`
+	Availability AvailabilityRules `json:"availability"`
+	PageState    *PageStatePaths   `json:"page_state"`
+
+	// CardPrice diz como deduzir o preço no cartão quando a loja só mostra
+	// o parcelamento: "price" usa o preço principal e "installments" o total
+	// das parcelas (ou o preço principal, se for "sem juros" e bater com ele).
+	CardPrice string `json:"card_price"`
+}
+
+// FieldRules lista as regras de cada campo do produto.
+type FieldRules struct {
+	Title        []FieldRule `json:"title"`
+	Image        []FieldRule `json:"image"`
+	Price        []FieldRule `json:"price"`
+	CashPrice    []FieldRule `json:"cash_price"`
+	CardPrice    []FieldRule `json:"card_price"`
+	ListPrice    []FieldRule `json:"list_price"`
+	Installments []FieldRule `json:"installments"`
+}
+
+// FieldRule lê um valor a partir de um seletor CSS.
+type FieldRule struct {
+	Selector string `json:"selector"`
+	// Attr lê o atributo em vez do texto do elemento.
+	Attr string `json:"attr"`
+	// Contains restringe aos elementos cujo texto contém a palavra.
+	Contains string `json:"contains"`
+	// Whole e Fraction são sub-seletores para lojas que exibem a parte
+	// inteira e os centavos em elementos separados.
+	Whole    string `json:"whole"`
+	Fraction string `json:"fraction"`
+	// Format "cash" procura um valor seguido de "no Pix"/"no boleto" no
+	// texto do elemento.
+	Format string `json:"format"`
+}
+
+// CanonicalRule reduz o caminho de uma URL de produto à forma mínima. URL é
+// um modelo com os grupos da expressão ($1, $2) e {host}, o domínio da loja
+// sem "www.".
+type CanonicalRule struct {
+	Path  string `json:"path"`
+	URL   string `json:"url"`
+	Upper bool   `json:"upper"`
+}
+
+// AvailabilityRules é consultado só quando os dados estruturados não
+// informam o estoque. Os seletores são testados pela presença do elemento;
+// Text lê frases como "Em estoque" ou "Esgotado".
+type AvailabilityRules struct {
+	OutOfStock     []string `json:"out_of_stock"`
+	Preorder       []string `json:"preorder"`
+	InStock        []string `json:"in_stock"`
+	Text           []string `json:"text"`
+	InStockIfPrice bool     `json:"in_stock_if_price"`
+}
+
+// StoresConfig aponta o diretório com definições extras ou que substituem
+// as embutidas (pelo mesmo nome) e de quanto em quanto tempo checá-lo.
+type StoresConfig struct {
+	Dir            string
+	ReloadInterval time.Duration
+}
+
+func StoresConfigFromEnv() StoresConfig {
+	return StoresConfig{
+		Dir:            os.Getenv("SCRAPER_STORES_DIR"),
+		ReloadInterval: envDuration("SCRAPER_STORES_RELOAD", 30*time.Second),
+	}
+}
+
+//go:embed stores/*.json
+var builtinStores embed.FS
+
+var (
+	storesMu     sync.Mutex
+	activeStores []*storeExtractor
+)
+
+func init() {
+	defs, err := readStoreDefinitions(builtinStores, "stores")
+	if err != nil {
+		panic(err)
+	}
+	if err := applyStoreDefinitions(defs); err != nil {
+		panic(err)
+	}
+}
+
+// ConfigureStores carrega as definições do diretório configurado e, se
+// ReloadInterval for positivo, passa a recarregá-las quando algum arquivo
+// mudar. Uma definição inválida num recarregamento é ignorada e as
+// anteriores continuam valendo.
+func ConfigureStores(cfg StoresConfig) error {
+	if cfg.Dir == "" {
+		return nil
+	}
+	if err := reloadStores(cfg.Dir); err != nil {
+		return err
+	}
+	if cfg.ReloadInterval > 0 {
+		go watchStores(cfg.Dir, cfg.ReloadInterval)
+	}
+	return nil
+}
+
+func reloadStores(dir string) error {
+	defs, err := readStoreDefinitions(builtinStores, "stores")
+	if err != nil {
+		return err
+	}
+	extra, err := readStoreDefinitions(os.DirFS(dir), ".")
+	if err != nil {
+		return err
+	}
+
+	byName := map[string]int{}
+	for i, d := range defs {
+		byName[d.Name] = i
+	}
+	for _, d := range extra {
+		if i, ok := byName[d.Name]; ok {
+			defs[i] = d
+			continue
+		}
+		defs = append(defs, d)
+	}
+	return applyStoreDefinitions(defs)
+}
+
+// watchStores compara nome, tamanho e data dos arquivos a cada intervalo;
+// polling evita depender de inotify em volumes montados.
+func watchStores(dir string, interval time.Duration) {
+	last := storesFingerprint(dir)
+	for {
+		time.Sleep(interval)
+		current := storesFingerprint(dir)
+		if current == last {
+			continue
+		}
+		last = current
+		if err := reloadStores(dir); err != nil {
+			log.Println("⚠️ Definições de lojas inválidas, mantendo as anteriores:", err)
+			continue
+		}
+		log.Println("🔄 Definições de lojas recarregadas de", dir)
+	}
+}
+
+func storesFingerprint(dir string) string {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	sort.Strings(files)
+
+	var b strings.Builder
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", f, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String()
+}
+
+func readStoreDefinitions(fsys fs.FS, dir string) ([]StoreDefinition, error) {
+	files, err := fs.Glob(fsys, filepath.ToSlash(filepath.Join(dir, "*.json")))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var defs []StoreDefinition
+	for _, name := range files {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		var d StoreDefinition
+		dec := json.NewDecoder(f)
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&d)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if d.Name == "" || len(d.Hosts) == 0 {
+			return nil, fmt.Errorf("%s: name e hosts são obrigatórios", name)
+		}
+		defs = append(defs, d)
+	}
+	return defs, nil
+}
+
+// applyStoreDefinitions compila todas as definições e só então troca as
+// registradas, para que um erro não deixe o registro pela metade.
+func applyStoreDefinitions(defs []StoreDefinition) error {
+	compiled := make([]*storeExtractor, 0, len(defs))
+	for _, d := range defs {
+		e, err := newStoreExtractor(d)
+		if err != nil {
+			return fmt.Errorf("loja %s: %w", d.Name, err)
+		}
+		compiled = append(compiled, e)
+	}
+
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	for _, e := range activeStores {
+		registry.remove(e.def.Hosts...)
+		if e.def.PageState != nil {
+			pageStateRules.remove(e.def.Hosts...)
+		}
+	}
+	for _, e := range compiled {
+		RegisterExtractor(e, e.def.Hosts...)
+		if e.def.PageState != nil {
+			RegisterPageStatePaths(*e.def.PageState, e.def.Hosts...)
+		}
+	}
+	activeStores = compiled
+	return nil
+}
+
+// storeExtractor é o Extractor montado a partir de uma StoreDefinition.
+type storeExtractor struct {
+	def       StoreDefinition
+	locale    money.Locale
+	canonical []canonicalPattern
+}
+
+type canonicalPattern struct {
+	re    *regexp.Regexp
+	url   string
+	upper bool
+}
+
+func newStoreExtractor(d StoreDefinition) (*storeExtractor, error) {
+	e := &storeExtractor{def: d}
+
+	switch strings.ToLower(d.Locale) {
+	case "", "pt-br":
+		e.locale = money.LocalePtBR
+	case "en-us":
+		e.locale = money.LocaleEnUS
+	default:
+		return nil, fmt.Errorf("locale desconhecido: %q", d.Locale)
+	}
+
+	switch d.CardPrice {
+	case "", "price", "installments":
+	default:
+		return nil, fmt.Errorf("card_price desconhecido: %q", d.CardPrice)
+	}
+
+	for _, c := range d.Canonical {
+		re, err := regexp.Compile(c.Path)
+		if err != nil {
+			return nil, fmt.Errorf("canonical %q: %w", c.Path, err)
+		}
+		e.canonical = append(e.canonical, canonicalPattern{re: re, url: c.URL, upper: c.Upper})
+	}
+	return e, nil
+}
+
+func (e *storeExtractor) Name() string { return e.def.Name }
+
+func (e *storeExtractor) Canonicalize(u *url.URL) *url.URL {
+	for _, c := range e.canonical {
+		path := u.Path
+		if c.upper {
+			path = strings.ToUpper(path)
+		}
+		m := c.re.FindStringSubmatchIndex(path)
+		if m == nil {
+			continue
+		}
+		tmpl := strings.ReplaceAll(c.url, "{host}", normalizeHost(u.Hostname()))
+		out, err := url.Parse(string(c.re.ExpandString(nil, tmpl, path, m)))
+		if err != nil {
+			continue
+		}
+		return out
+	}
+	return u
+}
+
+func (e *storeExtractor) Extract(doc *goquery.Document, pageURL *url.URL) ScrapeResult {
+	var r ScrapeResult
+	if !e.def.SkipGeneric {
+		r = genericExtractor{}.Extract(doc, pageURL)
+	}
+	f := e.def.Fields
+
+	if r.Title == "" {
+		r.Title = e.text(doc, f.Title)
+	}
+	if r.ImageURL == "" {
+		r.ImageURL = e.text(doc, f.Image)
+	}
+	if r.Price == 0 {
+		r.Price = e.amount(doc, f.Price)
+	}
+	r.CashPrice = e.amount(doc, f.CashPrice)
+	r.CardPrice = e.amount(doc, f.CardPrice)
+
+	n, each, interestFree := e.installments(doc, f.Installments)
+	r.Installments, r.InstallmentPrice = n, each
+	if r.CardPrice == 0 && n > 0 {
+		switch e.def.CardPrice {
+		case "price":
+			r.CardPrice = r.Price
+		case "installments":
+			// O preço em destaque pode ser o do Pix; sem juros, ele só vale
+			// como preço no cartão se bater com o total das parcelas (a
+			// diferença de até um centavo por parcela é arredondamento).
+			r.CardPrice = each.Mul(n)
+			if diff := r.CardPrice - r.Price; interestFree && diff >= -money.Amount(n) && diff <= money.Amount(n) {
+				r.CardPrice = r.Price
+			}
+		}
+	}
+
+	if r.ListPrice == 0 {
+		r.ListPrice = e.amount(doc, f.ListPrice)
+	}
+
+	if r.Availability == "" || r.Availability == AvailabilityUnknown {
+		r.Availability = e.availability(doc, r.Price)
+	}
+	return r
+}
+
+func (e *storeExtractor) availability(doc *goquery.Document, price money.Amount) Availability {
+	a := e.def.Availability
+	present := func(sels []string) bool {
+		for _, s := range sels {
+			if doc.Find(s).Length() > 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case present(a.OutOfStock):
+		return AvailabilityOutOfStock
+	case present(a.Preorder):
+		return AvailabilityPreorder
+	case present(a.InStock):
+		return AvailabilityInStock
+	}
+	for _, s := range a.Text {
+		if v := textAvailability(doc.Find(s).Text()); v != AvailabilityUnknown {
+			return v
+		}
+	}
+	if a.InStockIfPrice && price > 0 {
+		return AvailabilityInStock
+	}
+	return AvailabilityUnknown
+}
+
+// selection aplica o seletor e o filtro Contains da regra.
+func (rule FieldRule) selection(doc *goquery.Document) *goquery.Selection {
+	sel := doc.Find(rule.Selector)
+	if rule.Contains == "" {
+		return sel
+	}
+	word := strings.ToLower(rule.Contains)
+	return sel.FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return strings.Contains(strings.ToLower(s.Text()), word)
+	})
+}
+
+func (e *storeExtractor) text(doc *goquery.Document, rules []FieldRule) string {
+	for _, rule := range rules {
+		sel := rule.selection(doc).First()
+		v := strings.TrimSpace(sel.Text())
+		if rule.Attr != "" {
+			v = strings.TrimSpace(sel.AttrOr(rule.Attr, ""))
+		}
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (e *storeExtractor) amount(doc *goquery.Document, rules []FieldRule) money.Amount {
+	for _, rule := range rules {
+		sel := rule.selection(doc)
+		var a money.Amount
+		switch {
+		case rule.Format == "cash":
+			a = cashPriceFromText(sel.Text())
+		case rule.Whole != "":
+			a = e.splitAmount(sel.First(), rule)
+		case rule.Attr != "":
+			a = parsePrice(sel.First().AttrOr(rule.Attr, ""), e.locale)
+		default:
+			a = parsePrice(sel.First().Text(), e.locale)
+		}
+		if a > 0 {
+			return a
+		}
+	}
+	return 0
+}
+
+// splitAmount junta a parte inteira e os centavos exibidos em elementos
+// separados; ler só a parte inteira perderia os centavos.
+func (e *storeExtractor) splitAmount(sel *goquery.Selection, rule FieldRule) money.Amount {
+	whole := strings.TrimRight(strings.TrimSpace(sel.Find(rule.Whole).First().Text()), ",.")
+	if whole == "" {
+		return 0
+	}
+	fraction := ""
+	if rule.Fraction != "" {
+		fraction = strings.TrimSpace(sel.Find(rule.Fraction).First().Text())
+	}
+	if fraction == "" {
+		return parsePrice(whole, e.locale)
+	}
+	decimal := e.locale.Decimal
+	if decimal == 0 {
+		decimal = ','
+	}
+	return parsePrice(whole+string(decimal)+fraction, e.locale)
+}
+
+var installmentCountRe = regexp.MustCompile(`(\d{1,2})\s*x`)
+
+// installments lê o plano de parcelamento: "10x de R$ 99,90" no texto ou,
+// com Whole/Fraction, o número de parcelas no texto e o valor nos
+// sub-elementos.
+func (e *storeExtractor) installments(doc *goquery.Document, rules []FieldRule) (int, money.Amount, bool) {
+	for _, rule := range rules {
+		sel := rule.selection(doc)
+		text := sel.Text()
+		interestFree := strings.Contains(strings.ToLower(text), "sem juros")
+
+		if rule.Whole == "" {
+			if n, each := parseInstallments(text); n > 0 {
+				return n, each, interestFree
+			}
+			continue
+		}
+		m := installmentCountRe.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[1])
+		if each := e.splitAmount(sel, rule); n > 0 && each > 0 {
+			return n, each, interestFree
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,249 @@
+package web
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// storeFixture é uma página de produto salva em testdata/stores e o que os
+// extractors devem ler dela.
+type storeFixture struct {
+	file string
+	url  string
+	want ScrapeResult
+}
+
+func runStoreFixtures(t *testing.T, cases []storeFixture) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.file, func(t *testing.T) {
+			html, err := os.ReadFile(filepath.Join("testdata", "stores", c.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ExtractHTML(html, c.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Errorf("ExtractHTML =\n%+v\nqueria\n%+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestStoreFixtures(t *testing.T) {
+	runStoreFixtures(t, []storeFixture{
+		{
+			file: "magalu.html",
+			url:  "https://www.magazineluiza.com.br/smart-tv-50-crystal-uhd-4k-samsung-un50cu7700/p/237419900/et/elit/",
+			want: ScrapeResult{
+				Title:            `Smart TV 50" Crystal UHD 4K Samsung UN50CU7700`,
+				ImageURL:         "https://a-static.mlcdn.com.br/800x560/smart-tv-50-crystal-uhd-4k-samsung-un50cu7700/magazineluiza/237419900/3f0d0cbbd1a5c5b4bb5b3d2d1e0d1c43.jpg",
+				Price:            299900,
+				Currency:         "BRL",
+				CashPrice:        284905,
+				CardPrice:        299900,
+				Installments:     10,
+				InstallmentPrice: 29990,
+				ListPrice:        379900,
+				Availability:     AvailabilityInStock,
+				Extractor:        "magalu",
+				Strategy:         StrategyPageState,
+				Confidence:       1,
+			},
+		},
+		{
+			file: "casasbahia.html",
+			url:  "https://www.casasbahia.com.br/geladeira-brastemp-frost-free-duplex-375-litros-brm45hk-inox/p/55012345",
+			want: ScrapeResult{
+				Title:            "Geladeira Brastemp Frost Free Duplex 375 Litros BRM45HK Inox",
+				ImageURL:         "https://imgs.casasbahia.com.br/55012345/1g.jpg",
+				Price:            329900,
+				Currency:         "BRL",
+				SKU:              "55012345",
+				GTIN:             "7891129243576",
+				CashPrice:        313405,
+				CardPrice:        329900,
+				Installments:     10,
+				InstallmentPrice: 32990,
+				ListPrice:        399900,
+				Availability:     AvailabilityInStock,
+				Extractor:        "casasbahia",
+				Strategy:         StrategyJSONLD,
+				Confidence:       1,
+			},
+		},
+		{
+			file: "casasbahia_unavailable.html",
+			url:  "https://www.casasbahia.com.br/lavadora-de-roupas-electrolux-12kg-lac12-branca/p/15598765",
+			want: ScrapeResult{
+				Title:        "Lavadora de Roupas Electrolux 12kg LAC12 Branca",
+				ImageURL:     "https://imgs.casasbahia.com.br/15598765/1g.jpg",
+				Currency:     "BRL",
+				Availability: AvailabilityOutOfStock,
+				Extractor:    "casasbahia",
+				Strategy:     StrategyNone,
+			},
+		},
+		{
+			file: "americanas.html",
+			url:  "https://www.americanas.com.br/produto/5312345678/fone-de-ouvido-bluetooth-jbl-tune-520bt-preto",
+			want: ScrapeResult{
+				Title:            "Fone de Ouvido Bluetooth JBL Tune 520BT Preto",
+				ImageURL:         "https://images-americanas.b2w.io/produtos/5312345678/imagens/fone-de-ouvido-bluetooth-jbl-tune-520bt-preto/5312345678_1_large.jpg",
+				Price:            24990,
+				Currency:         "BRL",
+				CashPrice:        23741,
+				CardPrice:        24990,
+				Installments:     10,
+				InstallmentPrice: 2499,
+				ListPrice:        39900,
+				Availability:     AvailabilityInStock,
+				Extractor:        "americanas",
+				Strategy:         StrategySelectors,
+				Confidence:       1,
+			},
+		},
+		{
+			file: "shopee.html",
+			url:  "https://shopee.com.br/Kit-3-Camisetas-B%C3%A1sicas-Masculinas-i.123456789.22334455667",
+			want: ScrapeResult{
+				Title:            "Kit 3 Camisetas Básicas Masculinas 100% Algodão",
+				ImageURL:         "https://down-br.img.susercontent.com/file/br-11134207-7r98o-lq2m3n4b5c6d7e",
+				Price:            3990,
+				Currency:         "BRL",
+				CardPrice:        3990,
+				Installments:     3,
+				InstallmentPrice: 1330,
+				ListPrice:        5990,
+				Availability:     AvailabilityInStock,
+				Extractor:        "shopee",
+				Strategy:         StrategyPageState,
+				Confidence:       1,
+			},
+		},
+	})
+}
+
+// As definições de Mercado Livre, Amazon e Kabum substituíram extractors
+// escritos em Go; os valores abaixo são os que aqueles extractors liam das
+// mesmas páginas.
+func TestStoreFixturesMatchFormerExtractors(t *testing.T) {
+	runStoreFixtures(t, []storeFixture{
+		{
+			file: "mercadolivre.html",
+			url:  "https://www.mercadolivre.com.br/console-playstation-5-slim-digital-1tb-branco/p/MLB28366341",
+			want: ScrapeResult{
+				Title:            "Console Playstation 5 Slim Digital 1tb Branco",
+				ImageURL:         "https://http2.mlstatic.com/D_NQ_NP_2X_842115-MLU74251983321_012024-F.webp",
+				Price:            384990,
+				Currency:         "BRL",
+				CashPrice:        365740,
+				CardPrice:        384990,
+				Installments:     10,
+				InstallmentPrice: 38499,
+				ListPrice:        419900,
+				Availability:     AvailabilityInStock,
+				Extractor:        "mercadolivre",
+				Strategy:         StrategySelectors,
+				Confidence:       1,
+			},
+		},
+		{
+			file: "mercadolivre_paused.html",
+			url:  "https://produto.mercadolivre.com.br/MLB-1873456789-fone-sony-wh-1000xm4-_JM",
+			want: ScrapeResult{
+				Title:        "Fone Sony Wh-1000xm4 Bluetooth Cancelamento De Ruído Preto",
+				ImageURL:     "https://http2.mlstatic.com/D_NQ_NP_2X_611281-MLA45289873213_032021-F.webp",
+				Price:        169900,
+				Currency:     "BRL",
+				Availability: AvailabilityOutOfStock,
+				Extractor:    "mercadolivre",
+				Strategy:     StrategySelectors,
+				Confidence:   1,
+			},
+		},
+		{
+			file: "amazon.html",
+			url:  "https://www.amazon.com.br/Echo-Dot-5%C2%AA-gera%C3%A7%C3%A3o-Cor/dp/B09B8VGCR8/ref=sr_1_1",
+			want: ScrapeResult{
+				Title:            "Echo Dot 5ª geração | Smart speaker com Alexa | Cor Preta",
+				ImageURL:         "https://m.media-amazon.com/images/I/71xoR4A6q-L._AC_SL1000_.jpg",
+				Price:            39900,
+				Currency:         "BRL",
+				CashPrice:        37905,
+				CardPrice:        39900,
+				Installments:     10,
+				InstallmentPrice: 3990,
+				ListPrice:        44900,
+				Availability:     AvailabilityInStock,
+				Extractor:        "amazon",
+				Strategy:         StrategySelectors,
+				Confidence:       1,
+			},
+		},
+		{
+			file: "kabum.html",
+			url:  "https://www.kabum.com.br/produto/475647/placa-de-video-rtx-4060-ventus-2x-black-msi",
+			want: ScrapeResult{
+				Title:            "Placa de Vídeo RTX 4060 Ventus 2X Black MSI NVIDIA GeForce, 8GB GDDR6",
+				ImageURL:         "https://images.kabum.com.br/produtos/fotos/sync_mirakl/475647/Placa-De-V-deo-RTX-4060-Ventus-2X-Black-MSI_1688139836_gg.jpg",
+				Price:            189999,
+				Currency:         "BRL",
+				CashPrice:        189999,
+				CardPrice:        223528,
+				Installments:     10,
+				InstallmentPrice: 22353,
+				ListPrice:        249999,
+				Availability:     AvailabilityInStock,
+				Extractor:        "kabum",
+				Strategy:         StrategySelectors,
+				Confidence:       1,
+			},
+		},
+		{
+			file: "kabum_unavailable.html",
+			url:  "https://www.kabum.com.br/produto/320797/processador-amd-ryzen-7-5800x3d",
+			want: ScrapeResult{
+				Title:        "Processador AMD Ryzen 7 5800X3D, 3.4GHz (4.5GHz Max Turbo), AM4",
+				ImageURL:     "https://images.kabum.com.br/produtos/fotos/320797/processador-amd-ryzen-7-5800x3d_1649706296_gg.jpg",
+				Currency:     "BRL",
+				Availability: AvailabilityOutOfStock,
+				Extractor:    "kabum",
+				Strategy:     StrategyNone,
+			},
+		},
+	})
+}
+
+func TestStoreCanonicalize(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"https://www.mercadolivre.com.br/console-playstation-5-slim/p/MLB28366341?pdp_filters=category", "https://www.mercadolivre.com.br/p/MLB28366341"},
+		{"https://www.mercadolivre.com.br/console/p/mlb28366341", "https://www.mercadolivre.com.br/p/MLB28366341"},
+		{"https://produto.mercadolivre.com.br/MLB-1873456789-fone-sony-wh-1000xm4-_JM#position=3", "https://produto.mercadolivre.com.br/MLB-1873456789-_JM"},
+		{"https://produto.mercadolivre.com.br/mlb-1873456789-fone", "https://produto.mercadolivre.com.br/MLB-1873456789-_JM"},
+		{"https://www.mercadolivre.com.br/loja/sony", "https://www.mercadolivre.com.br/loja/sony"},
+		{"https://www.amazon.com.br/Echo-Dot/dp/B09B8VGCR8/ref=sr_1_1?keywords=echo", "https://www.amazon.com.br/dp/B09B8VGCR8"},
+		{"https://amazon.com.br/gp/product/B09B8VGCR8", "https://www.amazon.com.br/dp/B09B8VGCR8"},
+		{"https://www.amazon.com.br/gp/aw/d/B09B8VGCR8", "https://www.amazon.com.br/dp/B09B8VGCR8"},
+		{"https://www.kabum.com.br/produto/475647/placa-de-video-rtx-4060", "https://www.kabum.com.br/produto/475647"},
+		{"https://kabum.com.br/produto/475647", "https://www.kabum.com.br/produto/475647"},
+	}
+
+	for _, c := range cases {
+		u, err := url.Parse(c.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, ok := ExtractorFor(u.Hostname()).(*storeExtractor)
+		if !ok {
+			t.Fatalf("%s: sem definição de loja", c.in)
+		}
+		if got := e.Canonicalize(u).String(); got != c.want {
+			t.Errorf("Canonicalize(%s) = %s, queria %s", c.in, got, c.want)
+		}
+	}
+}
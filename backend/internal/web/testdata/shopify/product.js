@@ -0,0 +1,56 @@
+{
+  "id": 7981234567890,
+  "title": "Tênis Casual Couro Legítimo",
+  "handle": "tenis-casual-couro",
+  "vendor": "Loja Exemplo",
+  "type": "Calçados",
+  "tags": ["couro", "masculino"],
+  "price": 28990,
+  "price_min": 28990,
+  "price_max": 31990,
+  "available": true,
+  "compare_at_price": 39990,
+  "images": [
+    "//cdn.shopify.com/s/files/1/0612/3456/7890/products/tenis-casual-frente.jpg?v=1700000000",
+    "//cdn.shopify.com/s/files/1/0612/3456/7890/products/tenis-casual-lado.jpg?v=1700000000"
+  ],
+  "featured_image": "//cdn.shopify.com/s/files/1/0612/3456/7890/products/tenis-casual-frente.jpg?v=1700000000",
+  "variants": [
+    {
+      "id": 43210000000001,
+      "title": "39",
+      "option1": "39",
+      "sku": "TCC-39",
+      "barcode": "7890000000391",
+      "available": false,
+      "price": 28990,
+      "compare_at_price": 39990,
+      "featured_image": null
+    },
+    {
+      "id": 43210000000002,
+      "title": "40",
+      "option1": "40",
+      "sku": "TCC-40",
+      "barcode": "7890000000407",
+      "available": true,
+      "price": 28990,
+      "compare_at_price": 39990,
+      "featured_image": null
+    },
+    {
+      "id": 43210000000003,
+      "title": "41 - Edição Preta",
+      "option1": "41",
+      "sku": "TCC-41P",
+      "barcode": "7890000000414",
+      "available": true,
+      "price": 31990,
+      "compare_at_price": null,
+      "featured_image": {
+        "id": 36000000000001,
+        "src": "https://cdn.shopify.com/s/files/1/0612/3456/7890/products/tenis-casual-preto.jpg?v=1700000001"
+      }
+    }
+  ]
+}
@@ -0,0 +1,190 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// vtexPlatform lê produtos de lojas VTEX pela busca pública do catálogo
+// (/api/catalog_system/pub/products/search), que traz preço, preço "de",
+// parcelamento e estoque de cada SKU.
+type vtexPlatform struct{}
+
+func init() {
+	RegisterPlatform(vtexPlatform{})
+}
+
+func (vtexPlatform) Name() string { return "vtex" }
+
+func (vtexPlatform) Detect(doc *goquery.Document) bool {
+	if strings.Contains(strings.ToLower(doc.Find("meta[name='generator']").AttrOr("content", "")), "vtex") {
+		return true
+	}
+	return doc.Find("script[src*='vtex'], link[href*='vtexassets.com'], img[src*='vteximg.com.br'], img[src*='vtexassets.com']").Length() > 0
+}
+
+var errNotVTEXProduct = errors.New("URL fora do padrão /<produto>/p do VTEX")
+
+func (vtexPlatform) Fetch(ctx context.Context, productURL *url.URL) (ScrapeResult, error) {
+	api := url.URL{Scheme: productURL.Scheme, Host: productURL.Host, Path: "/api/catalog_system/pub/products/search"}
+
+	skuID := productURL.Query().Get("skuId")
+	switch path := strings.Trim(productURL.Path, "/"); {
+	case skuID != "":
+		api.RawQuery = url.Values{"fq": {"skuId:" + skuID}}.Encode()
+	case strings.HasSuffix(path, "/p"):
+		api.Path += "/" + path
+	default:
+		return ScrapeResult{}, &ScrapeError{Kind: ErrKindNotFound, Err: errNotVTEXProduct}
+	}
+
+	var products []vtexProduct
+	if err := fetchJSON(ctx, api.String(), &products); err != nil {
+		return ScrapeResult{}, err
+	}
+	return vtexResult(products, skuID)
+}
+
+type vtexProduct struct {
+	ProductID   string     `json:"productId"`
+	ProductName string     `json:"productName"`
+	LinkText    string     `json:"linkText"`
+	Items       []vtexItem `json:"items"`
+}
+
+type vtexItem struct {
+	ItemID string `json:"itemId"`
+	EAN    string `json:"ean"`
+	Images []struct {
+		ImageURL string `json:"imageUrl"`
+	} `json:"images"`
+	Sellers []vtexSeller `json:"sellers"`
+}
+
+type vtexSeller struct {
+	SellerDefault   bool      `json:"sellerDefault"`
+	CommertialOffer vtexOffer `json:"commertialOffer"`
+}
+
+type vtexOffer struct {
+	Price             json.Number       `json:"Price"`
+	ListPrice         json.Number       `json:"ListPrice"`
+	AvailableQuantity int               `json:"AvailableQuantity"`
+	Installments      []vtexInstallment `json:"Installments"`
+}
+
+type vtexInstallment struct {
+	Value                      json.Number `json:"Value"`
+	InterestRate               json.Number `json:"InterestRate"`
+	TotalValuePlusInterestRate json.Number `json:"TotalValuePlusInterestRate"`
+	NumberOfInstallments       int         `json:"NumberOfInstallments"`
+	PaymentSystemName          string      `json:"PaymentSystemName"`
+	PaymentSystemGroupName     string      `json:"PaymentSystemGroupName"`
+}
+
+// vtexResult converte a resposta da busca no resultado do SKU pedido ou,
+// sem skuId, do primeiro SKU com estoque. Um skuId ausente da resposta é
+// ErrKindNotFound.
+func vtexResult(products []vtexProduct, skuID string) (ScrapeResult, error) {
+	if len(products) == 0 || len(products[0].Items) == 0 {
+		return ScrapeResult{}, &ScrapeError{Kind: ErrKindNotFound, Err: errors.New("produto não encontrado na busca do VTEX")}
+	}
+	p := products[0]
+
+	item, found := p.Items[0], skuID == ""
+	for _, it := range p.Items {
+		if skuID != "" && it.ItemID == skuID {
+			item, found = it, true
+			break
+		}
+		if skuID == "" && vtexDefaultSeller(it).CommertialOffer.AvailableQuantity > 0 {
+			item = it
+			break
+		}
+	}
+	// Outro SKU do mesmo produto teria outro preço; melhor falhar.
+	if !found {
+		return ScrapeResult{}, &ScrapeError{Kind: ErrKindNotFound, Err: fmt.Errorf("SKU %s não encontrado na busca do VTEX", skuID)}
+	}
+	offer := vtexDefaultSeller(item).CommertialOffer
+
+	r := ScrapeResult{
+		Title: p.ProductName,
+		Price: jsonNumber(offer.Price),
+		SKU:   item.ItemID,
+		GTIN:  item.EAN,
+	}
+	if len(item.Images) > 0 {
+		r.ImageURL = item.Images[0].ImageURL
+	}
+	if list := jsonNumber(offer.ListPrice); list > r.Price {
+		r.ListPrice = list
+	}
+
+	r.Availability = AvailabilityOutOfStock
+	if offer.AvailableQuantity > 0 {
+		r.Availability = AvailabilityInStock
+	}
+
+	var card *vtexInstallment
+	for i := range offer.Installments {
+		in := &offer.Installments[i]
+		switch {
+		case vtexCashPayment(*in):
+			if v := jsonNumber(in.Value); v > 0 && (r.CashPrice == 0 || v < r.CashPrice) {
+				r.CashPrice = v
+			}
+		case in.PaymentSystemGroupName == "creditCardPaymentGroup":
+			if card == nil || vtexBetterPlan(*in, *card) {
+				card = in
+			}
+		}
+	}
+	if card != nil {
+		r.CardPrice = jsonNumber(card.TotalValuePlusInterestRate)
+		if card.NumberOfInstallments > 1 {
+			r.Installments = card.NumberOfInstallments
+			r.InstallmentPrice = jsonNumber(card.Value)
+		}
+	}
+	return r, nil
+}
+
+func vtexDefaultSeller(it vtexItem) vtexSeller {
+	for _, s := range it.Sellers {
+		if s.SellerDefault {
+			return s
+		}
+	}
+	if len(it.Sellers) > 0 {
+		return it.Sellers[0]
+	}
+	return vtexSeller{}
+}
+
+// vtexCashPayment reconhece Pix e boleto à vista.
+func vtexCashPayment(in vtexInstallment) bool {
+	if in.NumberOfInstallments > 1 {
+		return false
+	}
+	name := strings.ToLower(in.PaymentSystemName)
+	return in.PaymentSystemGroupName == "instantPaymentPaymentGroup" ||
+		in.PaymentSystemGroupName == "bankInvoicePaymentGroup" ||
+		strings.Contains(name, "pix") || strings.Contains(name, "boleto")
+}
+
+// vtexBetterPlan prefere o maior parcelamento sem juros; sem nenhum, o
+// maior parcelamento.
+func vtexBetterPlan(a, b vtexInstallment) bool {
+	aFree, bFree := jsonNumber(a.InterestRate) == 0, jsonNumber(b.InterestRate) == 0
+	if aFree != bFree {
+		return aFree
+	}
+	return a.NumberOfInstallments > b.NumberOfInstallments
+}
@@ -0,0 +1,207 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func loadVTEXFixture(t *testing.T, name string) []vtexProduct {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", "vtex", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var products []vtexProduct
+	if err := json.Unmarshal(raw, &products); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return products
+}
+
+func TestVTEXResult(t *testing.T) {
+	cases := []struct {
+		name    string
+		fixture string
+		skuID   string
+		want    ScrapeResult
+		wantErr ErrorKind
+	}{
+		{
+			name:    "em estoque",
+			fixture: "in_stock.json",
+			want: ScrapeResult{
+				Title:            `Notebook Acer Aspire 5 Intel Core i5 8GB 512GB SSD 15,6"`,
+				ImageURL:         "https://loja.vteximg.com.br/arquivos/ids/183920/aspire5-frente.jpg?v=638212233019870000",
+				Price:            329990,
+				ListPrice:        389990,
+				CardPrice:        329990,
+				Installments:     10,
+				InstallmentPrice: 32999,
+				SKU:              "91544",
+				GTIN:             "4711121568302",
+				Availability:     AvailabilityInStock,
+			},
+		},
+		{
+			name:    "esgotado",
+			fixture: "out_of_stock.json",
+			want: ScrapeResult{
+				Title:        "Fritadeira Air Fryer Mondial 4L Preta",
+				ImageURL:     "https://loja.vteximg.com.br/arquivos/ids/201455/afn40.jpg?v=638301120553700000",
+				SKU:          "97310",
+				GTIN:         "7899882312049",
+				Availability: AvailabilityOutOfStock,
+			},
+		},
+		{
+			name:    "sem skuId pega o primeiro com estoque e o seller padrão",
+			fixture: "multi_sku.json",
+			want: ScrapeResult{
+				Title:            "Camiseta Básica Algodão Masculina",
+				ImageURL:         "https://loja.vteximg.com.br/arquivos/ids/5102/camiseta-m.jpg",
+				Price:            8990,
+				ListPrice:        11990,
+				CardPrice:        8990,
+				Installments:     2,
+				InstallmentPrice: 4495,
+				SKU:              "102",
+				GTIN:             "7891000001024",
+				Availability:     AvailabilityInStock,
+			},
+		},
+		{
+			name:    "skuId pedido",
+			fixture: "multi_sku.json",
+			skuID:   "103",
+			want: ScrapeResult{
+				Title:        "Camiseta Básica Algodão Masculina",
+				ImageURL:     "https://loja.vteximg.com.br/arquivos/ids/5103/camiseta-g.jpg",
+				Price:        9490,
+				CardPrice:    9490,
+				SKU:          "103",
+				GTIN:         "7891000001031",
+				Availability: AvailabilityInStock,
+			},
+		},
+		{
+			name:    "skuId pedido esgotado",
+			fixture: "multi_sku.json",
+			skuID:   "101",
+			want: ScrapeResult{
+				Title:        "Camiseta Básica Algodão Masculina",
+				ImageURL:     "https://loja.vteximg.com.br/arquivos/ids/5101/camiseta-p.jpg",
+				SKU:          "101",
+				GTIN:         "7891000001017",
+				Availability: AvailabilityOutOfStock,
+			},
+		},
+		{
+			name:    "skuId fora da resposta",
+			fixture: "multi_sku.json",
+			skuID:   "999",
+			wantErr: ErrKindNotFound,
+		},
+		{
+			name:    "pix, boleto e cartão parcelado",
+			fixture: "cash_and_card.json",
+			want: ScrapeResult{
+				Title:            `Smart TV LED 50" 4K Samsung Crystal UHD`,
+				ImageURL:         "https://loja.vteximg.com.br/arquivos/ids/330012/tv-samsung-50.jpg?v=638390112233440000",
+				Price:            189900,
+				ListPrice:        249900,
+				CashPrice:        170910,
+				CardPrice:        189900,
+				Installments:     10,
+				InstallmentPrice: 18990,
+				SKU:              "120877",
+				GTIN:             "7892509124732",
+				Availability:     AvailabilityInStock,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := vtexResult(loadVTEXFixture(t, c.fixture), c.skuID)
+			if KindOf(err) != c.wantErr {
+				t.Fatalf("erro = %v, queria %q", err, c.wantErr)
+			}
+			if got != c.want {
+				t.Errorf("vtexResult =\n%+v\nqueria\n%+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestVTEXResultNotFound(t *testing.T) {
+	_, err := vtexResult(nil, "")
+	if KindOf(err) != ErrKindNotFound {
+		t.Fatalf("erro = %v, queria %s", err, ErrKindNotFound)
+	}
+}
+
+func TestVTEXFetchURL(t *testing.T) {
+	ConfigurePoliteness(PolitenessConfig{RequestsPerMinute: 6000, Burst: 10})
+	t.Cleanup(func() { ConfigurePoliteness(DefaultPolitenessConfig()) })
+
+	var got *url.URL
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL
+		http.ServeFile(w, r, filepath.Join("testdata", "vtex", "multi_sku.json"))
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		name  string
+		path  string
+		want  string
+		query string
+		sku   string
+	}{
+		{
+			name: "slug do produto",
+			path: "/camiseta-basica-algodao-masculina/p",
+			want: "/api/catalog_system/pub/products/search/camiseta-basica-algodao-masculina/p",
+			sku:  "102",
+		},
+		{
+			name:  "skuId na URL",
+			path:  "/camiseta-basica-algodao-masculina/p?skuId=103&utm_source=google",
+			want:  "/api/catalog_system/pub/products/search",
+			query: "skuId:103",
+			sku:   "103",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got = nil
+			u, _ := url.Parse(srv.URL + c.path)
+			r, err := vtexPlatform{}.Fetch(context.Background(), u)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got == nil {
+				t.Fatal("nenhuma requisição chegou ao servidor")
+			}
+			if got.Path != c.want || got.Query().Get("fq") != c.query {
+				t.Errorf("requisição = %s, queria %s com fq=%q", got, c.want, c.query)
+			}
+			if r.SKU != c.sku {
+				t.Errorf("SKU = %s, queria %s", r.SKU, c.sku)
+			}
+		})
+	}
+
+	u, _ := url.Parse(srv.URL + "/notebooks?page=2")
+	got = nil
+	if _, err := (vtexPlatform{}).Fetch(context.Background(), u); KindOf(err) != ErrKindNotFound || got != nil {
+		t.Errorf("URL fora do padrão: erro = %v, requisição = %v", err, got)
+	}
+}
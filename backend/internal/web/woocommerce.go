@@ -0,0 +1,125 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"price-analyzer-backend/internal/money"
+)
+
+// wooPlatform lê produtos de lojas WooCommerce pela Store API
+// (/wp-json/wc/store/v1/products), pública e sem autenticação.
+type wooPlatform struct{}
+
+func init() {
+	RegisterPlatform(wooPlatform{})
+}
+
+func (wooPlatform) Name() string { return "woocommerce" }
+
+func (wooPlatform) Detect(doc *goquery.Document) bool {
+	if strings.Contains(doc.Find("body").AttrOr("class", ""), "woocommerce") {
+		return true
+	}
+	return doc.Find("meta[name='generator'][content*='WooCommerce'], link[href*='/plugins/woocommerce/'], script[src*='/plugins/woocommerce/']").Length() > 0
+}
+
+// Versões antigas do plugin expõem a Store API sem o prefixo v1.
+var wooStoreAPIPaths = []string{"/wp-json/wc/store/v1/products", "/wp-json/wc/store/products"}
+
+func (wooPlatform) Fetch(ctx context.Context, productURL *url.URL) (ScrapeResult, error) {
+	// Variações são produtos próprios na Store API.
+	id := productURL.Query().Get("variation_id")
+	slug := path.Base(strings.TrimSuffix(productURL.Path, "/"))
+	if id == "" && (slug == "" || slug == "." || slug == "/") {
+		return ScrapeResult{}, &ScrapeError{Kind: ErrKindNotFound, Err: errors.New("URL sem o slug do produto WooCommerce")}
+	}
+
+	var err error
+	for _, p := range wooStoreAPIPaths {
+		api := url.URL{Scheme: productURL.Scheme, Host: productURL.Host, Path: p}
+
+		var product wooProduct
+		if id != "" {
+			api.Path += "/" + id
+			err = fetchJSON(ctx, api.String(), &product)
+		} else {
+			api.RawQuery = url.Values{"slug": {slug}}.Encode()
+			var products []wooProduct
+			err = fetchJSON(ctx, api.String(), &products)
+			if err == nil && len(products) == 0 {
+				err = &ScrapeError{Kind: ErrKindNotFound, Err: errors.New("produto não encontrado na Store API")}
+			}
+			if err == nil {
+				product = products[0]
+			}
+		}
+		if err == nil {
+			return wooResult(product), nil
+		}
+		if StatusCode(err) != 404 {
+			return ScrapeResult{}, err
+		}
+	}
+	return ScrapeResult{}, err
+}
+
+type wooProduct struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	SKU    string `json:"sku"`
+	Prices struct {
+		Price             string `json:"price"`
+		RegularPrice      string `json:"regular_price"`
+		SalePrice         string `json:"sale_price"`
+		CurrencyCode      string `json:"currency_code"`
+		CurrencyMinorUnit int    `json:"currency_minor_unit"`
+	} `json:"prices"`
+	IsInStock     bool `json:"is_in_stock"`
+	IsOnBackorder bool `json:"is_on_backorder"`
+	Images        []struct {
+		Src string `json:"src"`
+	} `json:"images"`
+}
+
+func wooResult(p wooProduct) ScrapeResult {
+	minor := p.Prices.CurrencyMinorUnit
+	r := ScrapeResult{
+		Title:    p.Name,
+		Price:    wooAmount(p.Prices.Price, minor),
+		Currency: strings.ToUpper(p.Prices.CurrencyCode),
+		SKU:      p.SKU,
+	}
+	if list := wooAmount(p.Prices.RegularPrice, minor); list > r.Price {
+		r.ListPrice = list
+	}
+	if len(p.Images) > 0 {
+		r.ImageURL = p.Images[0].Src
+	}
+
+	switch {
+	case p.IsOnBackorder:
+		r.Availability = AvailabilityPreorder
+	case p.IsInStock:
+		r.Availability = AvailabilityInStock
+	default:
+		r.Availability = AvailabilityOutOfStock
+	}
+	return r
+}
+
+// wooAmount converte o valor em unidades mínimas ("129990" com duas casas)
+// para Amount.
+func wooAmount(s string, minor int) money.Amount {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return fromMinorUnits(n, minor)
+}
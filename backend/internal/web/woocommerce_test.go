@@ -0,0 +1,146 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func loadWooFixture(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", "woocommerce", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+}
+
+func TestWooResult(t *testing.T) {
+	var search, clp []wooProduct
+	var variation wooProduct
+	loadWooFixture(t, "search.json", &search)
+	loadWooFixture(t, "out_of_stock_clp.json", &clp)
+	loadWooFixture(t, "variation_backorder.json", &variation)
+
+	cases := []struct {
+		name    string
+		product wooProduct
+		want    ScrapeResult
+	}{
+		{
+			name:    "em promoção",
+			product: search[0],
+			want: ScrapeResult{
+				Title:        "Cafeteira Italiana Moka 6 Xícaras",
+				ImageURL:     "https://cafe.exemplo.com.br/wp-content/uploads/2024/03/moka-6.jpg",
+				Price:        15990,
+				ListPrice:    19990,
+				Currency:     "BRL",
+				SKU:          "MOKA-6",
+				Availability: AvailabilityInStock,
+			},
+		},
+		{
+			name:    "variação sob encomenda",
+			product: variation,
+			want: ScrapeResult{
+				Title:        "Moedor de Café Manual - Inox",
+				Price:        24900,
+				Currency:     "BRL",
+				SKU:          "MOE-INOX",
+				Availability: AvailabilityPreorder,
+			},
+		},
+		{
+			name:    "moeda sem casas decimais e esgotado",
+			product: clp[0],
+			want: ScrapeResult{
+				Title:        "Café de Especialidad 500g",
+				ImageURL:     "https://tostaduria.exemplo.cl/wp-content/uploads/cafe-500.jpg",
+				Price:        1299000,
+				Currency:     "CLP",
+				SKU:          "CAFE-500",
+				Availability: AvailabilityOutOfStock,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := wooResult(c.product); got != c.want {
+				t.Errorf("wooResult =\n%+v\nqueria\n%+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestWooFetchURL(t *testing.T) {
+	ConfigurePoliteness(PolitenessConfig{RequestsPerMinute: 6000, Burst: 10})
+	t.Cleanup(func() { ConfigurePoliteness(DefaultPolitenessConfig()) })
+
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		switch r.URL.Path {
+		case "/wp-json/wc/store/products":
+			http.ServeFile(w, r, filepath.Join("testdata", "woocommerce", "search.json"))
+		case "/wp-json/wc/store/v1/products/1045":
+			http.ServeFile(w, r, filepath.Join("testdata", "woocommerce", "variation_backorder.json"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		name string
+		path string
+		want []string
+		sku  string
+	}{
+		{
+			name: "slug na Store API sem v1",
+			path: "/produto/cafeteira-italiana-moka-6-xicaras/",
+			want: []string{
+				"/wp-json/wc/store/v1/products?slug=cafeteira-italiana-moka-6-xicaras",
+				"/wp-json/wc/store/products?slug=cafeteira-italiana-moka-6-xicaras",
+			},
+			sku: "MOKA-6",
+		},
+		{
+			name: "variation_id",
+			path: "/produto/moedor-de-cafe-manual/?attribute_cor=inox&variation_id=1045",
+			want: []string{"/wp-json/wc/store/v1/products/1045"},
+			sku:  "MOE-INOX",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			requests = nil
+			u, _ := url.Parse(srv.URL + c.path)
+			r, err := wooPlatform{}.Fetch(context.Background(), u)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(requests) != len(c.want) {
+				t.Fatalf("requisições = %v, queria %v", requests, c.want)
+			}
+			for i := range c.want {
+				if requests[i] != c.want[i] {
+					t.Errorf("requisição %d = %s, queria %s", i, requests[i], c.want[i])
+				}
+			}
+			if r.SKU != c.sku {
+				t.Errorf("SKU = %s, queria %s", r.SKU, c.sku)
+			}
+		})
+	}
+}
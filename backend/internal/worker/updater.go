@@ -1,11 +1,18 @@
 package worker
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"sync"
 	"time"
 
+	"price-analyzer-backend/internal/analysis"
 	"price-analyzer-backend/internal/data"
+	"price-analyzer-backend/internal/fx"
+	"price-analyzer-backend/internal/money"
 	"price-analyzer-backend/internal/notifier"
 	"price-analyzer-backend/internal/web"
 )
@@ -14,7 +21,7 @@ func StartPriceMonitor() {
 	go func() {
 		for {
 			log.Println("🕵️ Worker: Verificando preços...")
-			
+
 			products, err := data.GetAllProductsForWorker()
 			if err != nil {
 				log.Println("❌ Erro ao buscar produtos:", err)
@@ -22,42 +29,204 @@ func StartPriceMonitor() {
 				continue
 			}
 
-			for _, p := range products {
-				time.Sleep(5 * time.Second)
-
-				_, _, currentPrice, err := web.ScrapeProduct(p.URL)
-				if err != nil {
-					log.Printf("Erro scraping %s: %v", p.Name, err)
-					continue
-				}
-
-				if currentPrice > 0 {
-					data.UpdatePrice(p.ID, currentPrice)
-
-					if p.TargetPrice > 0 && currentPrice <= p.TargetPrice {
-					
-						shouldNotify := !p.LastAlertAt.Valid || time.Since(p.LastAlertAt.Time) > 24*time.Hour
-						
-						if shouldNotify {
-							msg := fmt.Sprintf("🚨 *PREÇO CAIU!*\n\n📦 *%s*\n💰 Preço Atual: R$ %.2f\n🎯 Sua Meta: R$ %.2f\n\n[Ver Produto](%s)", 
-								p.Name, currentPrice, p.TargetPrice, p.URL)
-							
-							if p.TelegramChatID != "" {
-								err := notifier.SendTelegram(msg, p.TelegramChatID) 
-								if err == nil {
-									log.Printf("🔔 Notificação enviada para %s (User ID: %d)", p.Name, p.UserID)
-									data.UpdateLastAlert(p.ID)
-								}
-							} else {
-								log.Printf("⚠️ Alerta ignorado para %s: Usuário %d sem Telegram configurado.", p.Name, p.UserID)
-							}
-						}
-					}
-				}
-			}
+			runCycle(products)
 
 			log.Println("✅ Worker: Ciclo finalizado. Dormindo...")
 			time.Sleep(time.Minute * 5)
 		}
 	}()
-}
\ No newline at end of file
+}
+
+// runCycle verifica os produtos agrupados por loja: lojas diferentes rodam em
+// paralelo, e dentro de cada loja o limitador do pacote web dita o ritmo.
+func runCycle(products []data.Product) {
+	byStore := map[string][]data.Product{}
+	for _, p := range products {
+		host := web.StoreHost(p.URL)
+		byStore[host] = append(byStore[host], p)
+	}
+
+	sem := make(chan struct{}, parallelStores())
+	var wg sync.WaitGroup
+	for _, group := range byStore {
+		wg.Add(1)
+		go func(group []data.Product) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			for _, p := range group {
+				checkProduct(p)
+			}
+		}(group)
+	}
+	wg.Wait()
+}
+
+func parallelStores() int {
+	if n, err := strconv.Atoi(os.Getenv("WORKER_PARALLEL_STORES")); err == nil && n > 0 {
+		return n
+	}
+	return 4
+}
+
+func checkProduct(p data.Product) {
+	started := time.Now()
+	scraped, err := web.ScrapeProduct(p.URL)
+	if err == nil {
+		RecordAttempt(p.ID, started, scraped.Problem())
+	} else {
+		RecordAttempt(p.ID, started, err)
+	}
+
+	if errors.Is(err, web.ErrCircuitOpen) {
+		log.Printf("⏸️ %s pulado: loja %s suspensa pelo circuit breaker.", p.Name, web.StoreHost(p.URL))
+		return
+	}
+	if err != nil {
+		log.Printf("Erro scraping %s (%s): %v", p.Name, web.KindOf(err), err)
+		return
+	}
+	// Leitura sem preço de produto disponível é falha de extração: não vira
+	// amostra nem muda o estoque gravado, senão dispararia alertas com R$ 0,00.
+	if err := scraped.Problem(); err != nil {
+		log.Printf("Erro scraping %s (%s): %v", p.Name, web.KindOf(err), err)
+		return
+	}
+
+	if !acceptSample(p, scraped) {
+		return
+	}
+
+	currentPrice := scraped.Price
+	data.UpdatePrice(p.ID, scraped.Sample())
+
+	wasUnavailable := p.Availability == string(web.AvailabilityOutOfStock)
+	if p.StockAlert && wasUnavailable && scraped.Availability.IsAvailable() {
+		msg := fmt.Sprintf("✅ *DE VOLTA AO ESTOQUE!*\n\n📦 *%s*\n💰 Preço Atual: %s\n\n[Ver Produto](%s)",
+			p.Name, formatPrice(currentPrice, scraped.Currency, p.DisplayCurrency), p.URL)
+		notify(p, msg)
+		return
+	}
+
+	targetPrice := scraped.PriceFor(web.PriceKind(p.TargetType))
+
+	if targetPrice > 0 && scraped.Availability != web.AvailabilityOutOfStock {
+		if p.TargetPrice > 0 && targetPrice <= p.TargetPrice {
+			msg := fmt.Sprintf("🚨 *PREÇO CAIU!*\n\n📦 *%s*\n💰 Preço Atual: %s%s\n🎯 Sua Meta: %s\n\n[Ver Produto](%s)",
+				p.Name, formatPrice(targetPrice, scraped.Currency, p.DisplayCurrency), priceKindLabel(web.PriceKind(p.TargetType)),
+				formatPrice(p.TargetPrice, scraped.Currency, p.DisplayCurrency), p.URL)
+
+			if verdict, err := analysis.AnalyzeProduct(p.ID, p.UserID); err == nil && verdict.IsFake() {
+				msg += fmt.Sprintf("\n\n⚠️ *Atenção:* o preço subiu para %s nas últimas semanas antes desta queda. O desconto pode não ser real.",
+					fx.Format(verdict.PeakPrice, scraped.Currency))
+			}
+			notify(p, msg)
+		}
+	}
+}
+
+// acceptSample barra leituras que destoam demais do histórico recente (fração
+// sem milhar, valor da parcela no lugar do preço...). A leitura suspeita fica
+// guardada e só é aceita se a coleta seguinte confirmar o mesmo valor.
+func acceptSample(p data.Product, scraped web.ScrapeResult) bool {
+	if scraped.Price <= 0 {
+		return true
+	}
+
+	recent, err := data.GetRecentPrices(p.ID, 10)
+	if err != nil {
+		log.Printf("⚠️ Erro ao buscar histórico de %s para validação: %v", p.Name, err)
+		return true
+	}
+
+	check := analysis.CheckSample(recent, scraped.Price)
+	pending, err := data.GetPendingSuspicious(p.ID)
+	hasPending := err == nil && time.Since(pending.ScrapedAt) < 24*time.Hour
+
+	if hasPending && check.Suspicious && analysis.Confirms(pending.Price, scraped.Price) {
+		data.ResolveSuspicious(pending.ID, data.SuspiciousConfirmed)
+		log.Printf("✔️ Variação de %s confirmada por nova coleta: %s", p.Name, scraped.Price)
+		return true
+	}
+	if err == nil {
+		data.ResolveSuspicious(pending.ID, data.SuspiciousRejected)
+	}
+	if !check.Suspicious {
+		return true
+	}
+
+	data.InsertSuspiciousSample(data.SuspiciousSample{
+		ProductID:      p.ID,
+		Price:          scraped.Price,
+		ReferencePrice: check.Reference,
+		ChangePercent:  check.ChangePercent,
+		Reason:         check.Reason,
+	})
+	log.Printf("🧐 Leitura suspeita de %s: %s contra mediana de %s (%.1f%%). Aguardando confirmação.",
+		p.Name, scraped.Price, check.Reference, check.ChangePercent)
+	return false
+}
+
+// RecordAttempt grava o resultado de uma coleta em scrape_attempts.
+func RecordAttempt(productID int, started time.Time, err error) {
+	a := data.ScrapeAttempt{
+		ProductID:  productID,
+		StartedAt:  started,
+		DurationMS: int(time.Since(started).Milliseconds()),
+		Status:     data.AttemptSuccess,
+	}
+	if err != nil {
+		a.Status = data.AttemptError
+		a.Kind = string(web.KindOf(err))
+		a.Message = err.Error()
+		a.Code = web.StatusCode(err)
+	}
+
+	if err := data.RecordScrapeAttempt(a); err != nil {
+		log.Printf("❌ Erro ao registrar tentativa de coleta do produto %d: %v", productID, err)
+	}
+}
+
+// notify envia o alerta pelo Telegram respeitando o intervalo mínimo de 24h
+// entre alertas do mesmo produto (last_alert_at), seja qual for o tipo.
+func notify(p data.Product, msg string) {
+	shouldNotify := !p.LastAlertAt.Valid || time.Since(p.LastAlertAt.Time) > 24*time.Hour
+	if !shouldNotify {
+		return
+	}
+
+	if p.TelegramChatID == "" {
+		log.Printf("⚠️ Alerta ignorado para %s: Usuário %d sem Telegram configurado.", p.Name, p.UserID)
+		return
+	}
+
+	err := notifier.SendTelegram(msg, p.TelegramChatID)
+	if err == nil {
+		log.Printf("🔔 Notificação enviada para %s (User ID: %d)", p.Name, p.UserID)
+		data.UpdateLastAlert(p.ID)
+	}
+}
+
+func priceKindLabel(kind web.PriceKind) string {
+	switch kind {
+	case web.PriceCash:
+		return " (Pix/boleto)"
+	case web.PriceCard:
+		return " (cartão)"
+	}
+	return ""
+}
+
+// formatPrice mostra o valor na moeda da loja e, quando o usuário prefere
+// outra moeda, o valor convertido ao lado.
+func formatPrice(amount money.Amount, currency, display string) string {
+	text := fx.Format(amount, currency)
+	if fx.Normalize(display) == fx.Normalize(currency) {
+		return text
+	}
+	if converted, err := fx.Convert(amount, currency, display); err == nil {
+		text += " (≈ " + fx.Format(converted, display) + ")"
+	}
+	return text
+}